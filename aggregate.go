@@ -0,0 +1,103 @@
+package terr
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// aggregate is implemented by errors combining multiple errors, such as
+// Aggregate in k8s.io/apimachinery/pkg/util/errors and the errors returned by
+// Combine and Append in go.uber.org/multierr.
+type aggregate interface {
+	Errors() []error
+}
+
+// wrappedErrors is implemented by errors combining multiple errors in
+// github.com/hashicorp/go-multierror.
+type wrappedErrors interface {
+	WrappedErrors() []error
+}
+
+// joinedErrors is implemented by errors combining multiple errors in the
+// standard library (as returned by errors.Join) and in go.uber.org/multierr.
+type joinedErrors interface {
+	Unwrap() []error
+}
+
+var (
+	// childExtractorsMu serializes registrations of child extractors.
+	childExtractorsMu sync.Mutex
+	// childExtractors holds the functions registered with
+	// RegisterChildExtractor. It is replaced on every registration, so it can
+	// be read without locking.
+	childExtractors atomic.Pointer[[]func(error) []error]
+)
+
+// RegisterChildExtractor registers fn to extract the errors combined by
+// error types that terr does not know about, so Newf, Trace and TraceSkip
+// include each of them as a separate child, and TraceTree finds traced errors
+// among them, without terr depending on the packages defining these types. fn
+// must return nil for errors it does not handle. Extractors are tried in the
+// order they were registered, before the known interfaces for errors
+// combining multiple errors. This function is safe for concurrent use, but it
+// is meant to be called during program initialization.
+func RegisterChildExtractor(fn func(err error) []error) {
+	childExtractorsMu.Lock()
+	defer childExtractorsMu.Unlock()
+	var extractors []func(error) []error
+	if current := childExtractors.Load(); current != nil {
+		extractors = append(extractors, *current...)
+	}
+	extractors = append(extractors, fn)
+	childExtractors.Store(&extractors)
+}
+
+// extractedErrors returns the errors combined by err according to the
+// extractors registered with RegisterChildExtractor, or nil if none of them
+// handles err.
+func extractedErrors(err error) []error {
+	if extractors := childExtractors.Load(); extractors != nil {
+		for _, fn := range *extractors {
+			if errs := fn(err); errs != nil {
+				return errs
+			}
+		}
+	}
+	return nil
+}
+
+// aggregatedErrors returns the errors combined by err, or nil if err does not
+// combine multiple errors.
+func aggregatedErrors(err error) []error {
+	if errs := extractedErrors(err); errs != nil {
+		return errs
+	}
+	switch err := err.(type) {
+	case aggregate:
+		return err.Errors()
+	case wrappedErrors:
+		return err.WrappedErrors()
+	case joinedErrors:
+		return err.Unwrap()
+	}
+	return nil
+}
+
+// addAggregated adds the combined errors errs as children of e, so each of
+// them becomes a separate branch in the error tracing tree. Traced errors are
+// added along with their trees, nested aggregates are flattened, and other
+// errors are added as leaves located where e is.
+func (e *tracedError) addAggregated(errs []error) {
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if te, ok := err.(*tracedError); ok {
+			e.addChild(te)
+		} else if nested := aggregatedErrors(err); nested != nil {
+			e.addAggregated(nested)
+		} else {
+			e.addChild(&tracedError{error: err, pc: e.pc})
+		}
+	}
+}
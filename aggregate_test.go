@@ -0,0 +1,161 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+// aggregate mimics Aggregate in k8s.io/apimachinery/pkg/util/errors.
+type aggregate []error
+
+func (agg aggregate) Error() string {
+	msgs := make([]string, len(agg))
+	for i, err := range agg {
+		msgs[i] = err.Error()
+	}
+	return "[" + strings.Join(msgs, ", ") + "]"
+}
+
+func (agg aggregate) Errors() []error {
+	return agg
+}
+
+func TestAggregate(t *testing.T) {
+	file, line := getLocation(0)
+	err1 := terr.Newf("err1")
+	err2 := errors.New("err2")
+	nested := aggregate{terr.Newf("err3")}
+	err := terr.Newf("sync failed: %w", aggregate{err1, err2, nested})
+
+	assertEquals(t, err.Error(), "sync failed: [err1, err2, [err3]]")
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("sync failed: [err1, err2, [err3]] @ %s:%d", file, line+4),
+		fmt.Sprintf("\terr1 @ %s:%d", file, line+1),
+		fmt.Sprintf("\terr2 @ %s:%d", file, line+4),
+		fmt.Sprintf("\terr3 @ %s:%d", file, line+3),
+	}, "\n"))
+}
+
+func TestAggregateTrace(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Trace(aggregate{errors.New("err1"), errors.New("err2")})
+
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("[err1, err2] @ %s:%d", file, line+1),
+		fmt.Sprintf("\terr1 @ %s:%d", file, line+1),
+		fmt.Sprintf("\terr2 @ %s:%d", file, line+1),
+	}, "\n"))
+}
+
+// multiError mimics Error in github.com/hashicorp/go-multierror.
+type multiError struct {
+	Errors []error
+}
+
+func (e *multiError) Error() string {
+	return fmt.Sprintf("%d errors occurred", len(e.Errors))
+}
+
+func (e *multiError) WrappedErrors() []error {
+	return e.Errors
+}
+
+func TestMultiError(t *testing.T) {
+	file, line := getLocation(0)
+	err1 := terr.Newf("err1")
+	err2 := terr.Trace(errors.New("err2"))
+	err := terr.Trace(&multiError{Errors: []error{err1, err2, errors.New("err3")}})
+
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("3 errors occurred @ %s:%d", file, line+3),
+		fmt.Sprintf("\terr1 @ %s:%d", file, line+1),
+		fmt.Sprintf("\terr2 @ %s:%d", file, line+2),
+		fmt.Sprintf("\terr3 @ %s:%d", file, line+3),
+	}, "\n"))
+}
+
+// combinedError mimics the errors returned by Combine and Append in
+// go.uber.org/multierr.
+type combinedError struct {
+	errors []error
+}
+
+func (e *combinedError) Error() string {
+	msgs := make([]string, len(e.errors))
+	for i, err := range e.errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *combinedError) Errors() []error {
+	return e.errors
+}
+
+func (e *combinedError) Unwrap() []error {
+	return e.errors
+}
+
+func TestCombinedError(t *testing.T) {
+	file, line := getLocation(0)
+	err1 := terr.Newf("err1")
+	combined := &combinedError{[]error{err1, errors.New("err2")}}
+	err := terr.Newf("close: %v", combined)
+
+	assertEquals(t, errors.Is(combined, err1), true)
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("close: err1; err2 @ %s:%d", file, line+3),
+		fmt.Sprintf("\terr1 @ %s:%d", file, line+1),
+		fmt.Sprintf("\terr2 @ %s:%d", file, line+3),
+	}, "\n"))
+}
+
+func TestJoinedErrors(t *testing.T) {
+	file, line := getLocation(0)
+	err1 := terr.Newf("err1")
+	err := terr.Trace(errors.Join(err1, errors.New("err2")))
+
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("err1\nerr2 @ %s:%d", file, line+2),
+		fmt.Sprintf("\terr1 @ %s:%d", file, line+1),
+		fmt.Sprintf("\terr2 @ %s:%d", file, line+2),
+	}, "\n"))
+}
+
+// batchError combines multiple errors without implementing any of the
+// interfaces known by terr.
+type batchError struct {
+	failures []error
+}
+
+func (e *batchError) Error() string {
+	return fmt.Sprintf("%d items failed", len(e.failures))
+}
+
+func TestRegisterChildExtractor(t *testing.T) {
+	terr.RegisterChildExtractor(func(err error) []error {
+		if batch, ok := err.(*batchError); ok {
+			return batch.failures
+		}
+		return nil
+	})
+
+	file, line := getLocation(0)
+	err1 := terr.Newf("err1")
+	batch := &batchError{failures: []error{err1, errors.New("err2")}}
+	err := terr.Newf("sync failed: %w", batch)
+
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("sync failed: 2 items failed @ %s:%d", file, line+3),
+		fmt.Sprintf("\terr1 @ %s:%d", file, line+1),
+		fmt.Sprintf("\terr2 @ %s:%d", file, line+3),
+	}, "\n"))
+	assertEquals(t, terr.TraceTree(batch), terr.TraceTree(err1))
+	assertEquals(t, len(terr.TraceTree(terr.Trace(batch)).Children()), 2)
+}
@@ -0,0 +1,128 @@
+package terr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ExportAppInsights returns the traced error err as the data of an Azure
+// Application Insights exception telemetry item (ExceptionData), customized
+// by opts as in Export. Each traced error in the error tracing tree becomes an
+// exception, with its location as its only parsed stack frame, and children
+// become inner exceptions of their parents, identified by the id and outerId
+// fields. Exception types are the codes of the traced errors, or their kinds
+// if they have no codes. The metadata of the root, along with the host,
+// environment and labels set with SetServiceInfo, becomes the properties of
+// the item. Returns nil if err is not a traced error.
+func ExportAppInsights(err error, opts ...ExportOption) []byte {
+	te, ok := err.(*tracedError)
+	if !ok || te == nil {
+		return nil
+	}
+	cfg := defaultExportConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	te.report()
+
+	dst := []byte(`{"ver":2,"exceptions":[`)
+	dst = appendAppInsightsExceptions(dst, te, cfg)
+	dst = append(dst, ']')
+	var properties []metadatum
+	if !cfg.redact && !te.redacted {
+		properties = te.metadata
+	}
+	if info := cfg.service; info != nil {
+		if info.Host != "" {
+			properties = append(properties[:len(properties):len(properties)], metadatum{cfg.names.Host, info.Host})
+		}
+		if info.Environment != "" {
+			properties = append(properties[:len(properties):len(properties)], metadatum{cfg.names.Environment, info.Environment})
+		}
+		for _, k := range info.sortedLabels() {
+			properties = append(properties[:len(properties):len(properties)], metadatum{k, info.Labels[k]})
+		}
+	}
+	if len(properties) > 0 {
+		// Properties can only hold strings.
+		dst = append(dst, `,"properties":{`...)
+		for i, md := range properties {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, md.key)
+			dst = append(dst, ':')
+			dst = appendJSONString(dst, fmt.Sprint(md.value))
+		}
+		dst = append(dst, '}')
+	}
+	return append(dst, '}')
+}
+
+// appendAppInsightsExceptions appends the exceptions for the error tracing
+// tree rooted in et to dst, separated by commas and numbered in depth-first
+// order. Nodes are kept in an explicit stack, so arbitrarily deep trees cannot
+// overflow the goroutine stack.
+func appendAppInsightsExceptions(dst []byte, et ErrorTracer, cfg *exportConfig) []byte {
+	type exception struct {
+		et      ErrorTracer
+		outerID int
+	}
+	stack := []exception{{et, -1}}
+	for id := 0; len(stack) > 0; id++ {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		dst = appendAppInsightsException(dst, e.et, cfg, e.outerID, id)
+		groups := groupChildren(nil, childrenOf(e.et))
+		for i := len(groups) - 1; i >= 0; i-- {
+			stack = append(stack, exception{groups[i].ErrorTracer, id})
+		}
+	}
+	return dst
+}
+
+// appendAppInsightsException appends the exception for et to dst, preceded
+// by a comma if it is not the first one. id is the id of et, and outerID the
+// id of its parent, or -1 if it is the root.
+func appendAppInsightsException(dst []byte, et ErrorTracer, cfg *exportConfig, outerID, id int) []byte {
+	file, line := et.Location()
+	if cfg.path != nil {
+		file = cfg.path(file)
+	}
+	message := et.Error()
+	if cfg.redact || isRedacted(et) {
+		message = redactedMessage(et)
+	}
+	typeName, method := "error", ""
+	if te, ok := et.(*tracedError); ok {
+		if code := te.fullCode(); code != "" {
+			typeName = code
+		} else if te.kind != Unknown {
+			typeName = te.kind.String()
+		}
+		method = te.resolveLocation().function
+	}
+
+	if id > 0 {
+		dst = append(dst, ',')
+	}
+	dst = append(dst, `{"id":`...)
+	dst = strconv.AppendInt(dst, int64(id), 10)
+	if outerID >= 0 {
+		dst = append(dst, `,"outerId":`...)
+		dst = strconv.AppendInt(dst, int64(outerID), 10)
+	}
+	dst = append(dst, `,"typeName":`...)
+	dst = appendJSONString(dst, typeName)
+	dst = append(dst, `,"message":`...)
+	dst = appendJSONString(dst, message)
+	dst = append(dst, `,"hasFullStack":false,"parsedStack":[{"level":0,"method":`...)
+	dst = appendJSONString(dst, method)
+	if file != "" {
+		dst = append(dst, `,"fileName":`...)
+		dst = appendJSONString(dst, file)
+		dst = append(dst, `,"line":`...)
+		dst = strconv.AppendInt(dst, int64(line), 10)
+	}
+	return append(dst, "}]}"...)
+}
@@ -0,0 +1,26 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestExportAppInsights(t *testing.T) {
+	file, line := getLocation(0)
+	leaf := terr.Trace(errors.New("timeout"), terr.WithKind(terr.Timeout))
+	err := terr.Trace(terr.Newf("query: %w", leaf), terr.WithCode("query_failed"), terr.WithMetadata("attempt", 3))
+
+	fn := "github.com/alnvdl/terr_test.TestExportAppInsights"
+	assertEquals(t, string(terr.ExportAppInsights(err)), fmt.Sprintf(`{"ver":2,"exceptions":[`+
+		`{"id":0,"typeName":"query_failed","message":"query: timeout","hasFullStack":false,"parsedStack":[{"level":0,"method":%q,"fileName":%q,"line":%d}]},`+
+		`{"id":1,"outerId":0,"typeName":"error","message":"query: timeout","hasFullStack":false,"parsedStack":[{"level":0,"method":%q,"fileName":%q,"line":%d}]},`+
+		`{"id":2,"outerId":1,"typeName":"timeout","message":"timeout","hasFullStack":false,"parsedStack":[{"level":0,"method":%q,"fileName":%q,"line":%d}]}`+
+		`],"properties":{"attempt":"3"}}`,
+		fn, file, line+2, fn, file, line+2, fn, file, line+1))
+	assertEquals(t, terr.ExportAppInsights(errors.New("fail")) == nil, true)
+}
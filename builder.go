@@ -0,0 +1,111 @@
+package terr
+
+import (
+	"errors"
+	"time"
+)
+
+// Builder constructs error tracing trees node by node, without creating
+// traced errors with Newf or Trace. It is meant for tooling importing traces
+// from external systems and for test fixtures. Each Builder describes a
+// single node, and children are added as other Builders.
+type Builder struct {
+	message  string
+	file     string
+	line     int
+	opts     []TraceOption
+	children []*Builder
+}
+
+// NewBuilder returns a Builder for a node with the given message and no
+// location.
+func NewBuilder(message string) *Builder {
+	return &Builder{message: message}
+}
+
+// Location sets the location of the node and returns b.
+func (b *Builder) Location(file string, line int) *Builder {
+	b.file, b.line = file, line
+	return b
+}
+
+// Metadata attaches a key-value pair to the node, as WithMetadata does, and
+// returns b.
+func (b *Builder) Metadata(key string, value any) *Builder {
+	return b.Options(WithMetadata(key, value))
+}
+
+// Time sets the time when the node occurred, flagging it as imported, as
+// WithTime does, and returns b.
+func (b *Builder) Time(t time.Time) *Builder {
+	return b.Options(WithTime(t))
+}
+
+// Options adds options to customize the node (e.g., WithCode or WithHint)
+// and returns b.
+func (b *Builder) Options(opts ...TraceOption) *Builder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// Child adds children to the node, after the ones already added, and returns
+// b. Nil children are ignored.
+func (b *Builder) Child(children ...*Builder) *Builder {
+	for _, child := range children {
+		if child != nil {
+			b.children = append(b.children, child)
+		}
+	}
+	return b
+}
+
+// Build returns a traced error for the error tracing tree described by b.
+// Defaults set with SetDefaults are not applied, and the limit set with
+// SetMaxChildren is. Builders can be built more than once, and each call
+// returns a new traced error.
+func (b *Builder) Build() error {
+	return b.build()
+}
+
+// build returns a new traced error for the tree described by b. Builders
+// whose children are being built are kept in an explicit stack, so
+// arbitrarily deep trees cannot overflow the goroutine stack.
+func (b *Builder) build() *tracedError {
+	// buildFrame is a builder whose traced error gets its options once all
+	// of its children are built.
+	type buildFrame struct {
+		b    *Builder
+		e    *tracedError
+		next int
+	}
+	frames := []buildFrame{{b: b, e: b.newNode()}}
+	for {
+		f := &frames[len(frames)-1]
+		if f.next < len(f.b.children) {
+			child := f.b.children[f.next]
+			f.next++
+			frames = append(frames, buildFrame{b: child, e: child.newNode()})
+			continue
+		}
+		for _, opt := range f.b.opts {
+			opt(f.e)
+		}
+		// Configs only apply to traced errors created by this package at a
+		// given location.
+		f.e.config = nil
+		e := f.e
+		frames = frames[:len(frames)-1]
+		if len(frames) == 0 {
+			return e
+		}
+		frames[len(frames)-1].e.addChild(e)
+	}
+}
+
+// newNode returns a new traced error for the node described by b, without
+// its children and options.
+func (b *Builder) newNode() *tracedError {
+	e := &tracedError{error: errors.New(b.message)}
+	e.loc.Store(&location{file: b.file, line: b.line})
+	return e
+}
@@ -0,0 +1,36 @@
+package terr_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestBuilder(t *testing.T) {
+	b := terr.NewBuilder("request failed").Location("api.go", 10).Child(
+		terr.NewBuilder("query failed").Location("db.go", 20).Metadata("table", "users").Child(
+			terr.NewBuilder("connection refused").Location("conn.go", 30),
+		),
+		terr.NewBuilder("cleanup failed").Location("db.go", 40).Options(terr.WithCode("cleanup")),
+		nil,
+	)
+	err := b.Build()
+
+	assertEquals(t, err.Error(), "request failed")
+	assertEquals(t, terr.Code(err), "cleanup")
+	assertEquals(t, terr.Sprint(err), strings.Join([]string{
+		"request failed @ api.go:10",
+		"\tquery failed @ db.go:20 [table=users]",
+		"\t\tconnection refused @ conn.go:30",
+		"\tcleanup failed @ db.go:40",
+	}, "\n"))
+
+	b2, jsonErr := json.Marshal(b.Build())
+	assertErrorIsNil(t, jsonErr)
+	b1, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(b2), string(b1))
+	assertEquals(t, terr.Sprint(terr.NewBuilder("fail").Build()), "fail")
+}
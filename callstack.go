@@ -0,0 +1,152 @@
+package terr
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+)
+
+// maxStackDepth is the default maximum number of frames captured for the
+// call stacks of traced errors.
+const maxStackDepth = 64
+
+// captureStack returns the program counters of up to depth frames of the
+// call stack, skipping a number of frames, with 0 identifying the caller of
+// captureStack. If depth is not positive, maxStackDepth is used.
+func captureStack(skip, depth int) []uintptr {
+	if depth <= 0 {
+		depth = maxStackDepth
+	}
+	if depth > maxStackDepth {
+		pcs := make([]uintptr, depth)
+		n := runtime.Callers(2+skip, pcs)
+		return pcs[:n:n]
+	}
+	// Frames are captured into an array first, so only the frames found are
+	// kept in the traced error.
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(2+skip, pcs[:depth])
+	return append([]uintptr(nil), pcs[:n]...)
+}
+
+// WithStack captures up to depth frames of the call stack of the traced
+// error, as if Config.Stack and Config.StackDepth were set, so errors
+// originating deep in helper code can be traced back through their callers
+// even if they are not traced at every level. Frames are skipped according to
+// the Config set with WithConfig or SetDefaults, so WithStack must be given
+// after WithConfig, which replaces it. Like Config.Stack, it has no effect in
+// the reduced-footprint mode selected with the terr_tiny build tag.
+func WithStack(depth int) TraceOption {
+	return func(e *tracedError) {
+		c := Config{}
+		if e.config != nil {
+			c = *e.config
+		}
+		c.Stack, c.StackDepth = true, depth
+		e.config = &c
+	}
+}
+
+// NewfStack works exactly like Newf, but also captures up to depth frames of
+// the call stack of the returned traced error, as if WithStack was used.
+func NewfStack(depth int, format string, a ...any) error {
+	return newf(fmt.Errorf(format, a...), a, []TraceOption{WithStack(depth)})
+}
+
+// Stack returns the frames of the call stack captured for et with WithStack,
+// NewfStack or Config.Stack, ordered from the innermost (where et was created)
+// to the outermost, as in stack traces. Returns nil if no stack was captured
+// for et.
+func Stack(et ErrorTracer) []runtime.Frame {
+	te, ok := et.(*tracedError)
+	if !ok || len(te.stack) == 0 {
+		return nil
+	}
+	var frames []runtime.Frame
+	it := runtime.CallersFrames(te.stack)
+	for {
+		frame, more := it.Next()
+		frames = append(frames, frame)
+		if !more {
+			return frames
+		}
+	}
+}
+
+// stackFrames returns the frames of the call stack captured for et, if any,
+// from the outermost to the innermost, as "function @ file:line" strings.
+// File paths are transformed by path, if it is not nil.
+func stackFrames(et ErrorTracer, path func(string) string) []string {
+	te, ok := et.(*tracedError)
+	if !ok || len(te.stack) == 0 {
+		return nil
+	}
+	var frames []string
+	it := runtime.CallersFrames(te.stack)
+	for {
+		frame, more := it.Next()
+		file := frame.File
+		if path != nil {
+			file = path(file)
+		}
+		frames = append(frames, frame.Function+" @ "+file+":"+strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+// sharedStack returns the longest prefix of frames shared by the stacks of
+// the groups of children, if at least two of them have stacks, along with the
+// stack of each group without that prefix. Each stack keeps at least its
+// innermost frame. Returns nil stacks if no group has a stack.
+func sharedStack(groups []childGroup, path func(string) string) (shared []string, stacks [][]string) {
+	n := 0
+	for i, g := range groups {
+		frames := stackFrames(g.ErrorTracer, path)
+		if frames == nil {
+			continue
+		}
+		if stacks == nil {
+			stacks = make([][]string, len(groups))
+		}
+		stacks[i] = frames
+		if n++; n == 1 {
+			shared = frames[:len(frames)-1]
+			continue
+		}
+		common := 0
+		for common < len(shared) && common < len(frames)-1 && shared[common] == frames[common] {
+			common++
+		}
+		shared = shared[:common]
+	}
+	if n < 2 || len(shared) == 0 {
+		return nil, stacks
+	}
+	for i, frames := range stacks {
+		if frames != nil {
+			stacks[i] = frames[len(shared):]
+		}
+	}
+	return shared, stacks
+}
+
+// appendJSONStack appends the frames of a stack to dst as a JSON array under
+// key, preceded by a comma, returning the extended buffer.
+func appendJSONStack(dst []byte, key string, frames []string) []byte {
+	dst = append(dst, ',')
+	dst = appendJSONString(dst, key)
+	dst = append(dst, ":["...)
+	for i, frame := range frames {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, frame)
+	}
+	return append(dst, ']')
+}
@@ -0,0 +1,93 @@
+//go:build !terr_tiny && !tinygo && !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestMarshalJSONSharedStack(t *testing.T) {
+	opts := []terr.TraceOption{terr.WithConfig(terr.Config{Stack: true})}
+	fail := func(msg string) error {
+		return terr.NewfWith(opts, msg)
+	}
+
+	file, line := getLocation(0)
+	first := fail("first")
+	err := terr.Newf("batch: %w", errors.Join(first, fail("second")))
+	err = terr.Newf("partial: %w", errors.Join(err, fail("third")))
+
+	type node struct {
+		Stack       []string `json:"stack"`
+		SharedStack []string `json:"shared_stack"`
+		Children    []node   `json:"children"`
+	}
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	var root node
+	assertErrorIsNil(t, json.Unmarshal(b, &root))
+	test := "github.com/alnvdl/terr_test.TestMarshalJSONSharedStack"
+
+	// Only one child of the root has a stack, so nothing is shared.
+	assertEquals(t, len(root.Stack), 0)
+	assertEquals(t, len(root.SharedStack), 0)
+	third := root.Children[1].Stack
+	assertEquals(t, strings.HasPrefix(third[0], "runtime.goexit @ "), true)
+	assertEquals(t, third[len(third)-2], fmt.Sprintf("%s @ %s:%d", test, file, line+3))
+	assertEquals(t, third[len(third)-1], fmt.Sprintf("%s.func1 @ %s:%d", test, file, line-3))
+
+	// The children of batch share the frames outside the test function, so
+	// they are encoded only once, in batch.
+	batch := root.Children[0]
+	assertEquals(t, strings.Join(batch.SharedStack, "\n"), strings.Join(third[:len(third)-2], "\n"))
+	assertEquals(t, strings.Join(batch.Children[0].Stack, "\n"), strings.Join([]string{
+		fmt.Sprintf("%s @ %s:%d", test, file, line+1),
+		fmt.Sprintf("%s.func1 @ %s:%d", test, file, line-3),
+	}, "\n"))
+	assertEquals(t, strings.Join(batch.Children[1].Stack, "\n"), strings.Join([]string{
+		fmt.Sprintf("%s @ %s:%d", test, file, line+2),
+		fmt.Sprintf("%s.func1 @ %s:%d", test, file, line-3),
+	}, "\n"))
+
+	// Without Config.Stack, no stacks are included.
+	b, jsonErr = json.Marshal(terr.Newf("batch: %w", errors.Join(terr.Newf("first"), terr.Newf("second"))))
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, strings.Contains(string(b), `"stack"`), false)
+}
+
+func newfStackHelper(depth int) error {
+	return terr.NewfStack(depth, "deep")
+}
+
+func TestWithStack(t *testing.T) {
+	test := "github.com/alnvdl/terr_test.TestWithStack"
+	file, line := getLocation(0)
+	err := newfStackHelper(2)
+	traced := terr.Trace(errors.New("fail"), terr.WithStack(1))
+	skipped := terr.TraceSkip(errors.New("fail"), 0, terr.WithConfig(terr.Config{Skip: 1}), terr.WithStack(1))
+
+	frames := terr.Stack(terr.TraceTree(err))
+	assertEquals(t, len(frames), 2)
+	assertEquals(t, frames[0].Function, "github.com/alnvdl/terr_test.newfStackHelper")
+	assertEquals(t, frames[1].Function, test)
+	assertEquals(t, fmt.Sprintf("%s:%d", frames[1].File, frames[1].Line), fmt.Sprintf("%s:%d", file, line+1))
+
+	frames = terr.Stack(terr.TraceTree(traced))
+	assertEquals(t, len(frames), 1)
+	assertEquals(t, fmt.Sprintf("%s:%d", frames[0].File, frames[0].Line), fmt.Sprintf("%s:%d", file, line+2))
+
+	// Frames skipped by the Config are also skipped in the stack.
+	frames = terr.Stack(terr.TraceTree(skipped))
+	assertEquals(t, len(frames), 1)
+	assertEquals(t, strings.HasPrefix(frames[0].Function, "testing."), true)
+
+	// Without a depth, up to 64 frames are captured.
+	assertEquals(t, len(terr.Stack(terr.TraceTree(newfStackHelper(0)))) > 2, true)
+	assertEquals(t, len(terr.Stack(terr.TraceTree(terr.Newf("fail")))), 0)
+}
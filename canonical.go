@@ -0,0 +1,123 @@
+package terr
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// ExportCanonical works like Export, but returns a canonical JSON
+// representation, so identical error tracing trees always serialize to the
+// same bytes, even across processes. This makes it suitable for hashing,
+// caching and deduplicating trees. In the canonical representation:
+//   - object keys are sorted, and the default field names are always used;
+//   - file paths are normalized with NormalizePath, after any other path
+//     transformation set by opts;
+//   - error IDs and service information, which vary between occurrences of
+//     the same tree, are omitted.
+//
+// Like Export, it also finds traced errors wrapped by non-traced errors.
+// Returns nil if err has no traced error.
+func ExportCanonical(err error, opts ...ExportOption) []byte {
+	te := findTraced(err)
+	if te == nil {
+		return nil
+	}
+	cfg := &exportConfig{names: defaultFieldNames}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if transform := cfg.path; transform != nil {
+		cfg.path = func(file string) string { return NormalizePath(transform(file)) }
+	} else {
+		cfg.path = NormalizePath
+	}
+	cfg.names, cfg.service, cfg.canonical = defaultFieldNames, nil, true
+	te.report()
+
+	dec := json.NewDecoder(bytes.NewReader(appendJSON(nil, te, cfg, 1)))
+	dec.UseNumber()
+	value, decErr := decodeOrdered(dec)
+	if decErr != nil {
+		// The JSON representation is always valid.
+		panic(decErr)
+	}
+	return appendCanonicalJSON(nil, value)
+}
+
+// appendCanonicalJSON appends value, as decoded by decodeOrdered, to dst as
+// JSON with the keys of objects sorted, keeping only the last entry for
+// repeated keys, as json.Marshal does for maps. Objects and arrays being
+// appended are kept in an explicit stack, so arbitrarily deep values cannot
+// overflow the goroutine stack.
+func appendCanonicalJSON(dst []byte, value any) []byte {
+	// canonicalFrame is an object or array whose entries are being
+	// appended.
+	type canonicalFrame struct {
+		isObject bool
+		object   orderedMap
+		array    []any
+		next     int
+	}
+	var frames []canonicalFrame
+	for {
+		switch v := value.(type) {
+		case orderedMap:
+			dst = append(dst, '{')
+			frames = append(frames, canonicalFrame{isObject: true, object: sortedEntries(v)})
+		case []any:
+			dst = append(dst, '[')
+			frames = append(frames, canonicalFrame{array: v})
+		default:
+			dst = append(dst, v.(string)...)
+		}
+
+		// Find the next value to append, closing the objects and arrays that
+		// have none left.
+		for {
+			if len(frames) == 0 {
+				return dst
+			}
+			f := &frames[len(frames)-1]
+			if f.isObject && f.next < len(f.object) {
+				if f.next > 0 {
+					dst = append(dst, ',')
+				}
+				dst = appendJSONString(dst, f.object[f.next].key)
+				dst = append(dst, ':')
+				value = f.object[f.next].value
+				f.next++
+				break
+			}
+			if !f.isObject && f.next < len(f.array) {
+				if f.next > 0 {
+					dst = append(dst, ',')
+				}
+				value = f.array[f.next]
+				f.next++
+				break
+			}
+			if f.isObject {
+				dst = append(dst, '}')
+			} else {
+				dst = append(dst, ']')
+			}
+			frames = frames[:len(frames)-1]
+		}
+	}
+}
+
+// sortedEntries returns a copy of m sorted by key, keeping only the last
+// entry for repeated keys.
+func sortedEntries(m orderedMap) orderedMap {
+	sorted := append(orderedMap(nil), m...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].key < sorted[j].key })
+	entries := sorted[:0]
+	for i, entry := range sorted {
+		if i+1 < len(sorted) && sorted[i+1].key == entry.key {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
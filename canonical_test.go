@@ -0,0 +1,34 @@
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestExportCanonical(t *testing.T) {
+	terr.SetFieldNames(terr.ECSFieldNames)
+	defer terr.SetFieldNames(terr.FieldNames{})
+	terr.SetServiceInfo(terr.ServiceInfo{Host: "web-1"})
+	defer terr.SetServiceInfo(terr.ServiceInfo{})
+
+	build := func(file string, metadata ...string) error {
+		b := terr.NewBuilder("query failed").Location(file, 10).Options(terr.WithID(), terr.WithCode("db"))
+		for i := 0; i < len(metadata); i += 2 {
+			b.Metadata(metadata[i], metadata[i+1])
+		}
+		return b.Child(terr.NewBuilder("timeout").Location("db.go", 20)).Build()
+	}
+	a := build(`C:\src\db\query.go`, "table", "users", "attempt", "3")
+	b := build("/src/db/query.go", "attempt", "3", "table", "users")
+
+	want := `{"children":[{"file":"db.go","line":20,"message":"timeout"}],"code":"db","file":"/src/db/query.go","line":10,"message":"query failed","metadata":{"attempt":"3","table":"users"}}`
+	assertEquals(t, string(terr.ExportCanonical(a)), want)
+	assertEquals(t, string(terr.ExportCanonical(b)), want)
+	assertEquals(t, string(terr.ExportCanonical(a, terr.StripPaths())),
+		`{"children":[{"file":"db.go","line":20,"message":"timeout"}],"code":"db","file":"db/query.go","line":10,"message":"query failed","metadata":{"attempt":"3","table":"users"}}`)
+	assertEquals(t, string(terr.ExportCanonical(fmt.Errorf("wrapped: %w", a))), want)
+	assertEquals(t, terr.ExportCanonical(errors.New("fail")) == nil, true)
+}
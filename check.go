@@ -0,0 +1,70 @@
+package terr
+
+import "fmt"
+
+// checkPanic is the value Check panics with, to be recovered by Handle.
+type checkPanic struct {
+	err error
+	// te is the traced error located where Check was called, or nil if no
+	// traced error was created.
+	te *tracedError
+}
+
+// Error returns the message of the checked error, so unhandled panics raised
+// by Check are readable.
+func (p checkPanic) Error() string {
+	return p.err.Error()
+}
+
+// Check panics if err is not nil, so a deferred Handle can return it from the
+// enclosing function. This lets straight-line code propagate errors without
+// repeating if err != nil blocks:
+//
+//	func load(path string) (cfg Config, err error) {
+//		defer terr.Handle(&err, "load %s: %w", path)
+//		data, err := os.ReadFile(path)
+//		terr.Check(err)
+//		terr.Check(json.Unmarshal(data, &cfg))
+//		return cfg, nil
+//	}
+//
+// The error is traced where Check is called. Check must only be used in
+// functions that defer Handle.
+func Check(err error) {
+	if err == nil {
+		return
+	}
+	panic(checkPanic{err: err, te: newTracedError(err, []any{err}, 0, nil)})
+}
+
+// Handle recovers a panic raised by Check and stores the checked error in
+// errp, annotated by format. The checked error is appended to a, so format
+// must have a verb for it, usually %w to keep it wrapped. The resulting
+// traced error is located where Check was called, so each function using
+// Check and Handle adds a hop to the error tracing tree. Handle must be
+// deferred directly, and other panics are re-raised.
+func Handle(errp *error, format string, a ...any) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	p, ok := r.(checkPanic)
+	if !ok {
+		panic(r)
+	}
+	a = append(a[:len(a):len(a)], p.err)
+	err := fmt.Errorf(format, a...)
+	if p.te == nil {
+		*errp = err
+		return
+	}
+	// The traced error is only reachable through the panic, so it can still
+	// be changed safely.
+	p.te.error = err
+	for _, arg := range a[:len(a)-1] {
+		if child, ok := arg.(*tracedError); ok {
+			p.te.addChild(child)
+		}
+	}
+	*errp = p.te
+}
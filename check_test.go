@@ -0,0 +1,53 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestCheckHandle(t *testing.T) {
+	base := errors.New("base")
+	var innerLine, outerLine int
+	inner := func(fail bool) (n int, err error) {
+		defer terr.Handle(&err, "inner(%v): %w", fail)
+		if fail {
+			_, innerLine = getLocation(0)
+			terr.Check(base)
+		}
+		return 1, nil
+	}
+	outer := func() (err error) {
+		defer terr.Handle(&err, "outer: %w")
+		n, err := inner(false)
+		terr.Check(err)
+		assertEquals(t, n, 1)
+		_, outerLine = getLocation(0)
+		_, err = inner(true)
+		terr.Check(err)
+		return nil
+	}
+
+	err := outer()
+	file, _ := getLocation(0)
+	assertEquals(t, errors.Is(err, base), true)
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("outer: inner(true): base @ %s:%d", file, outerLine+2),
+		fmt.Sprintf("\tinner(true): base @ %s:%d", file, innerLine+1),
+	}, "\n"))
+}
+
+func TestHandleRepanics(t *testing.T) {
+	defer func() {
+		assertEquals(t, recover(), any("boom"))
+	}()
+	func() (err error) {
+		defer terr.Handle(&err, "%w")
+		panic("boom")
+	}()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// analyze reports the most frequent root causes, the hottest locations and
+// the most frequent error tracing trees found in serialized traces.
+func analyze(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	top := fs.Int("n", 10, "number of entries to report in each section")
+	key := fs.String("key", "", "read traces from this top-level key of JSON log records")
+	fields := fs.String("fields", "default", "field names of the traces: default, ecs, otel or gcp")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	names, err := fieldNames(*fields)
+	if err != nil {
+		return err
+	}
+
+	traces, skipped, err := readTraces(fs.Args(), stdin, *key, names)
+	if err != nil {
+		return err
+	}
+
+	rootCauses := newCounter[*node]()
+	locations := newCounter[*node]()
+	trees := newCounter[*node]()
+	for _, trace := range traces {
+		trace.leaves(1, func(leaf *node, weight int) {
+			rootCauses.add(leaf.location(), weight, leaf)
+		})
+		trace.walk(1, func(n *node, weight int) {
+			locations.add(n.location(), weight, n)
+		})
+		trees.add(trace.fingerprint(), 1, trace)
+	}
+
+	fmt.Fprintf(stdout, "Traces analyzed: %d\n", len(traces))
+	if skipped > 0 {
+		fmt.Fprintf(stdout, "Lines skipped: %d\n", skipped)
+	}
+
+	fmt.Fprintf(stdout, "\nTop root causes:\n")
+	for _, e := range rootCauses.top(*top) {
+		fmt.Fprintf(stdout, "%8d  %s\n          e.g.: %s\n", e.count, e.key, e.example.Message)
+	}
+
+	fmt.Fprintf(stdout, "\nHottest locations:\n")
+	for _, e := range locations.top(*top) {
+		fmt.Fprintf(stdout, "%8d  %s\n", e.count, e.key)
+	}
+
+	fmt.Fprintf(stdout, "\nMost frequent trees:\n")
+	for _, e := range trees.top(*top) {
+		fmt.Fprintf(stdout, "%8d  fingerprint %s\n", e.count, e.key)
+		for _, line := range strings.Split(e.example.String(), "\n") {
+			fmt.Fprintf(stdout, "          %s\n", line)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const analyzeInput = `{"message":"a: timeout","file":"a.go","line":10,"children":[{"message":"timeout","file":"db.go","line":5}]}
+{"message":"a: timeout","file":"a.go","line":10,"children":[{"message":"timeout","file":"db.go","line":5}]}
+not a trace
+{"message":"b: batch","file":"b.go","line":20,"children":[{"message":"invalid","file":"v.go","line":3,"count":3}]}
+
+{"level":"ERROR","msg":"failed"}
+`
+
+func TestAnalyze(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"analyze", "-n", "2"}, strings.NewReader(analyzeInput), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("want exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	want := `Traces analyzed: 3
+Lines skipped: 2
+
+Top root causes:
+       3  v.go:3
+          e.g.: invalid
+       2  db.go:5
+          e.g.: timeout
+
+Hottest locations:
+       3  v.go:3
+       2  a.go:10
+
+Most frequent trees:
+       2  fingerprint 7a9a1d1f6cfc79f2
+          a: timeout @ a.go:10
+          	timeout @ db.go:5
+       1  fingerprint 2a8bcb3ddba2c3a1
+          b: batch @ b.go:20
+          	invalid @ v.go:3 (x3)
+`
+	got := stdout.String()
+	// Fingerprints are opaque, so they are not compared.
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(want, "\n")
+	if len(gotLines) != len(wantLines) {
+		t.Fatalf("want:\n%s\ngot:\n%s", want, got)
+	}
+	for i := range gotLines {
+		if strings.Contains(wantLines[i], "fingerprint") {
+			continue
+		}
+		if gotLines[i] != wantLines[i] {
+			t.Fatalf("want:\n%s\ngot:\n%s", want, got)
+		}
+	}
+}
+
+func TestAnalyzeKey(t *testing.T) {
+	input := `{"level":"ERROR","err":{"message":"a","file":"a.go","line":1,"children":{"0":{"message":"b","file":"b.go","line":2},"1":{"message":"c","file":"c.go","line":3}}}}` + "\n"
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"analyze", "-key", "err"}, strings.NewReader(input), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("want exit code 0, got %d: %s", code, stderr.String())
+	}
+	for _, s := range []string{"Traces analyzed: 1\n", "1  b.go:2\n", "1  c.go:3\n", "\tb @ b.go:2\n"} {
+		if !strings.Contains(stdout.String(), s) {
+			t.Fatalf("want output containing %q, got:\n%s", s, stdout.String())
+		}
+	}
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"unknown"}, nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("want exit code 2, got %d", code)
+	}
+	if code := run(nil, nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("want exit code 2, got %d", code)
+	}
+}
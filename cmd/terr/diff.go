@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// diff compares two sets of serialized traces, reporting new, disappeared
+// and changed failure fingerprints.
+func diff(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	key := fs.String("key", "", "read traces from this top-level key of JSON log records")
+	fields := fs.String("fields", "default", "field names of the traces: default, ecs, otel or gcp")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	names, err := fieldNames(*fields)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("want 2 files (before and after), got %d", fs.NArg())
+	}
+
+	count := func(file string) (*counter[*node], error) {
+		traces, _, err := readTraces([]string{file}, stdin, *key, names)
+		if err != nil {
+			return nil, err
+		}
+		c := newCounter[*node]()
+		for _, trace := range traces {
+			c.add(trace.fingerprint(), 1, trace)
+		}
+		return c, nil
+	}
+	before, err := count(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := count(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	var added, removed, changed []entry[*node]
+	for _, e := range after.top(0) {
+		if n, ok := before.counts[e.key]; !ok {
+			added = append(added, e)
+		} else if n != e.count {
+			changed = append(changed, e)
+		}
+	}
+	for _, e := range before.top(0) {
+		if _, ok := after.counts[e.key]; !ok {
+			removed = append(removed, e)
+		}
+	}
+	// Report the largest changes first.
+	sort.SliceStable(changed, func(i, j int) bool {
+		return abs(changed[i].count-before.counts[changed[i].key]) >
+			abs(changed[j].count-before.counts[changed[j].key])
+	})
+
+	printEntries := func(title string, entries []entry[*node], countRepr func(e entry[*node]) string) {
+		fmt.Fprintf(stdout, "%s: %d\n", title, len(entries))
+		for _, e := range entries {
+			fmt.Fprintf(stdout, "%8s  fingerprint %s\n", countRepr(e), e.key)
+			for _, line := range strings.Split(e.example.String(), "\n") {
+				fmt.Fprintf(stdout, "          %s\n", line)
+			}
+		}
+	}
+	count1 := func(e entry[*node]) string {
+		return fmt.Sprint(e.count)
+	}
+	printEntries("New", added, count1)
+	fmt.Fprintln(stdout)
+	printEntries("Disappeared", removed, count1)
+	fmt.Fprintln(stdout)
+	printEntries("Changed", changed, func(e entry[*node]) string {
+		return fmt.Sprintf("%d->%d", before.counts[e.key], e.count)
+	})
+	return nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	before := filepath.Join(dir, "before.jsonl")
+	after := filepath.Join(dir, "after.jsonl")
+	os.WriteFile(before, []byte(`{"message":"a","file":"a.go","line":1}
+{"message":"b","file":"b.go","line":2}
+{"message":"b","file":"b.go","line":2}
+`), 0o644)
+	os.WriteFile(after, []byte(`{"message":"b","file":"b.go","line":2}
+{"message":"c","file":"c.go","line":3}
+`), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"diff", before, after}, nil, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("want exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	want := `New: 1
+       1  fingerprint X
+          c @ c.go:3
+
+Disappeared: 1
+       1  fingerprint X
+          a @ a.go:1
+
+Changed: 1
+    2->1  fingerprint X
+          b @ b.go:2
+`
+	got := regexp.MustCompile(`fingerprint \w+`).ReplaceAllString(stdout.String(), "fingerprint X")
+	if got != want {
+		t.Fatalf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestDiffArgs(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"diff", "a.jsonl"}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("want exit code 1, got %d", code)
+	}
+}
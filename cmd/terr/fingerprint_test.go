@@ -0,0 +1,48 @@
+//go:build !terr_noop
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestAnalyzeFingerprint(t *testing.T) {
+	var errs []error
+	for i := 0; i < 3; i++ {
+		errs = append(errs, terr.NewfWith([]terr.TraceOption{terr.WithCode("invalid")}, "invalid"))
+	}
+	err := terr.Domain("batch").Newf("batch: %w", errors.Join(errs...))
+
+	for _, profile := range []string{"default", "ecs", "otel", "gcp"} {
+		names, _ := fieldNames(profile)
+		terr.SetFieldNames(fieldProfiles[profile])
+		b, jsonErr := json.Marshal(err)
+		terr.SetFieldNames(terr.FieldNames{})
+		if jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		if !strings.Contains(string(b), names.Count) {
+			t.Fatalf("want repeated children in %s", b)
+		}
+
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"analyze", "-fields", profile}, bytes.NewReader(b), &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("want exit code 0, got %d: %s", code, stderr.String())
+		}
+		if want := "fingerprint " + terr.Fingerprint(err) + "\n"; !strings.Contains(stdout.String(), want) {
+			t.Fatalf("%s: want output containing %q, got:\n%s", profile, want, stdout.String())
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"analyze", "-fields", "unknown"}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("want exit code 1, got %d", code)
+	}
+}
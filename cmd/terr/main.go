@@ -0,0 +1,62 @@
+// Command terr provides tools for working with error tracing trees serialized
+// as JSON by the terr package, one tree per line.
+//
+// Usage:
+//
+//	terr <command> [flags] [files...]
+//
+// The commands are:
+//
+//	analyze    report the most frequent root causes, locations and trees
+//	diff       compare two sets of traces (e.g., before and after a deploy)
+//
+// If no files are given, traces are read from standard input. Traces encoded
+// with other field names than the defaults (see terr.SetFieldNames) can be
+// read with the -fields flag, which selects the ecs, otel or gcp names. Trees
+// are identified by the same fingerprints returned by terr.Fingerprint.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: terr <command> [flags] [files...]
+
+commands:
+  analyze    report the most frequent root causes, locations and trees
+  diff       compare two sets of traces (e.g., before and after a deploy)
+
+Run "terr <command> -h" for the flags of each command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command in args, returning the exit code for the process.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "analyze":
+		err = analyze(args[1:], stdin, stdout, stderr)
+	case "diff":
+		err = diff(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "terr: unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "terr %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alnvdl/terr"
+)
+
+// node is a node in a serialized error tracing tree.
+type node struct {
+	Message  string
+	File     string
+	Line     int
+	Code     string
+	Count    int
+	Children []*node
+}
+
+// fieldProfiles are the field names that can be selected with the -fields
+// flag.
+var fieldProfiles = map[string]terr.FieldNames{
+	"default": {},
+	"ecs":     terr.ECSFieldNames,
+	"otel":    terr.OTelFieldNames,
+	"gcp":     terr.GCPFieldNames,
+}
+
+// fieldNames returns the field names of the given profile, with empty names
+// replaced by their defaults, as terr.SetFieldNames does.
+func fieldNames(profile string) (terr.FieldNames, error) {
+	names, ok := fieldProfiles[profile]
+	if !ok {
+		return names, fmt.Errorf("unknown field names %q", profile)
+	}
+	for _, f := range []struct {
+		name *string
+		def  string
+	}{
+		{&names.Message, "message"},
+		{&names.File, "file"},
+		{&names.Line, "line"},
+		{&names.Code, "code"},
+		{&names.Count, "count"},
+		{&names.Children, "children"},
+	} {
+		if *f.name == "" {
+			*f.name = f.def
+		}
+	}
+	return names, nil
+}
+
+// decodeNode decodes a tree encoded by json.Marshal with the given field
+// names, with children as arrays, as well as trees emitted by
+// slog.JSONHandler, with children as objects keyed by their indexes.
+func decodeNode(b []byte, names terr.FieldNames) (*node, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	loc := fields
+	if names.Location != "" {
+		loc = nil
+		if err := decodeField(fields, names.Location, &loc); err != nil {
+			return nil, err
+		}
+	}
+	if loc[names.File] == nil {
+		return nil, fmt.Errorf("missing file in error tracing tree node")
+	}
+	n := &node{}
+	for _, f := range []struct {
+		fields map[string]json.RawMessage
+		key    string
+		dst    any
+	}{
+		{fields, names.Message, &n.Message},
+		{loc, names.File, &n.File},
+		{loc, names.Line, &n.Line},
+		{fields, names.Code, &n.Code},
+		{fields, names.Count, &n.Count},
+	} {
+		if err := decodeField(f.fields, f.key, f.dst); err != nil {
+			return nil, err
+		}
+	}
+	if n.Count < 1 {
+		n.Count = 1
+	}
+
+	children := strings.TrimSpace(string(fields[names.Children]))
+	var raw []json.RawMessage
+	switch {
+	case children == "" || children == "null":
+	case children[0] == '[':
+		if err := json.Unmarshal(fields[names.Children], &raw); err != nil {
+			return nil, err
+		}
+	default:
+		var indexed map[string]json.RawMessage
+		if err := json.Unmarshal(fields[names.Children], &indexed); err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(indexed))
+		for k := range indexed {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			a, _ := strconv.Atoi(keys[i])
+			b, _ := strconv.Atoi(keys[j])
+			return a < b
+		})
+		for _, k := range keys {
+			raw = append(raw, indexed[k])
+		}
+	}
+	for _, b := range raw {
+		child, err := decodeNode(b, names)
+		if err != nil {
+			return nil, err
+		}
+		n.Children = append(n.Children, child)
+	}
+	return n, nil
+}
+
+// decodeField decodes the value of key in fields into dst, if it is present.
+func decodeField(fields map[string]json.RawMessage, key string, dst any) error {
+	if fields[key] == nil {
+		return nil
+	}
+	if err := json.Unmarshal(fields[key], dst); err != nil {
+		return fmt.Errorf("invalid %q in error tracing tree node: %w", key, err)
+	}
+	return nil
+}
+
+// location returns the location of n in the file:line format.
+func (n *node) location() string {
+	return fmt.Sprintf("%s:%d", n.File, n.Line)
+}
+
+// leaves calls fn for all leaf nodes in the tree rooted in n, along with the
+// number of times they were repeated in the tree.
+func (n *node) leaves(weight int, fn func(leaf *node, weight int)) {
+	weight *= n.Count
+	if len(n.Children) == 0 {
+		fn(n, weight)
+		return
+	}
+	for _, child := range n.Children {
+		child.leaves(weight, fn)
+	}
+}
+
+// walk calls fn for all nodes in the tree rooted in n, along with the number
+// of times they were repeated in the tree.
+func (n *node) walk(weight int, fn func(n *node, weight int)) {
+	weight *= n.Count
+	fn(n, weight)
+	for _, child := range n.Children {
+		child.walk(weight, fn)
+	}
+}
+
+// fingerprint returns the fingerprint of the tree rooted in n, as returned
+// by terr.Fingerprint for the traced error it was encoded from. Like in that
+// function, it is derived from the locations and codes in the tree, and
+// repeated children are hashed as many times as they were repeated.
+func (n *node) fingerprint() string {
+	h := fnv.New64a()
+	var write func(n *node)
+	write = func(n *node) {
+		fmt.Fprintf(h, "%s:%d#%s(", n.File, n.Line, n.Code)
+		for _, child := range n.Children {
+			for i := 0; i < child.Count; i++ {
+				write(child)
+			}
+		}
+		h.Write([]byte{')'})
+	}
+	write(n)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// String returns the tree rooted in n in the same format used by the %@ verb.
+func (n *node) String() string {
+	var sb strings.Builder
+	var write func(n *node, depth int)
+	write = func(n *node, depth int) {
+		if depth > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(strings.Repeat("\t", depth))
+		fmt.Fprintf(&sb, "%s @ %s", n.Message, n.location())
+		if n.Count > 1 {
+			fmt.Fprintf(&sb, " (x%d)", n.Count)
+		}
+		for _, child := range n.Children {
+			write(child, depth+1)
+		}
+	}
+	write(n, 0)
+	return sb.String()
+}
+
+// readTraces reads error tracing trees from files, one JSON-encoded tree per
+// line with the given field names, or from stdin if no files are given. If
+// key is not empty, trees are read from that top-level key of JSON objects
+// (e.g., in structured logs). Lines that do not contain a tree are skipped
+// and counted.
+func readTraces(files []string, stdin io.Reader, key string, names terr.FieldNames) (traces []*node, skipped int, err error) {
+	read := func(r io.Reader) error {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(strings.TrimSpace(string(line))) == 0 {
+				continue
+			}
+			if key != "" {
+				var record map[string]json.RawMessage
+				if json.Unmarshal(line, &record) != nil || record[key] == nil {
+					skipped++
+					continue
+				}
+				line = record[key]
+			}
+			n, err := decodeNode(line, names)
+			if err != nil {
+				skipped++
+				continue
+			}
+			traces = append(traces, n)
+		}
+		return scanner.Err()
+	}
+
+	if len(files) == 0 {
+		return traces, skipped, read(stdin)
+	}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, 0, err
+		}
+		err = read(f)
+		f.Close()
+		if err != nil {
+			return nil, 0, fmt.Errorf("cannot read %s: %w", file, err)
+		}
+	}
+	return traces, skipped, nil
+}
+
+// counter counts occurrences of keys, keeping an example value for each key.
+type counter[T any] struct {
+	counts   map[string]int
+	examples map[string]T
+}
+
+func newCounter[T any]() *counter[T] {
+	return &counter[T]{counts: make(map[string]int), examples: make(map[string]T)}
+}
+
+// add adds n occurrences of key, keeping example if it is the first one.
+func (c *counter[T]) add(key string, n int, example T) {
+	if _, ok := c.counts[key]; !ok {
+		c.examples[key] = example
+	}
+	c.counts[key] += n
+}
+
+// entry is a key in a counter, along with its count and example.
+type entry[T any] struct {
+	key     string
+	count   int
+	example T
+}
+
+// top returns the n keys with the most occurrences, in descending order of
+// occurrences. Ties are broken by key.
+func (c *counter[T]) top(n int) []entry[T] {
+	entries := make([]entry[T], 0, len(c.counts))
+	for key, count := range c.counts {
+		entries = append(entries, entry[T]{key, count, c.examples[key]})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].key < entries[j].key
+	})
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
@@ -0,0 +1,102 @@
+// Command terrmigrate rewrites calls to fmt.Errorf and errors.New inside
+// function bodies into calls to terr.Newf, to ease the adoption of terr in
+// existing code bases. Imports are added and removed as needed, and %w
+// semantics are preserved, since terr.Newf works exactly like fmt.Errorf.
+// Package-level calls, typically used for sentinel errors, are not rewritten.
+//
+// Usage:
+//
+//	terrmigrate [flags] [paths...]
+//
+// Paths can be files or directories, which are processed recursively,
+// skipping vendor and testdata directories. If no paths are given, the
+// current directory is processed. By default, rewritten files are printed to
+// standard output.
+//
+// The flags are:
+//
+//	-l	list files that would be rewritten
+//	-w	write rewritten files in place
+//	-tests
+//		also rewrite _test.go files
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes terrmigrate with args, returning the exit code for the
+// process.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("terrmigrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	list := flags.Bool("l", false, "list files that would be rewritten")
+	write := flags.Bool("w", false, "write rewritten files in place")
+	tests := flags.Bool("tests", false, "also rewrite _test.go files")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	process := func(path string) error {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out, changed, err := rewrite(path, src)
+		if err != nil || !changed {
+			return err
+		}
+		if *list {
+			fmt.Fprintln(stdout, path)
+		}
+		if *write {
+			return os.WriteFile(path, out, 0o644)
+		}
+		if !*list {
+			_, err = stdout.Write(out)
+		}
+		return err
+	}
+
+	exitCode := 0
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root && (d.Name() == "vendor" || d.Name() == "testdata" || strings.HasPrefix(d.Name(), ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(path, ".go") || (!*tests && strings.HasSuffix(path, "_test.go")) {
+				return nil
+			}
+			if err := process(path); err != nil {
+				fmt.Fprintf(stderr, "terrmigrate: %v\n", err)
+				exitCode = 1
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "terrmigrate: %v\n", err)
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
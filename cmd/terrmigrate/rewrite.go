@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+const terrPath = "github.com/alnvdl/terr"
+
+// rewrite rewrites calls to fmt.Errorf and errors.New inside function bodies
+// in src into calls to terr.Newf, adding and removing imports as needed. It
+// returns the rewritten source and whether any call was rewritten. Calls at
+// the package level (e.g., sentinel errors) are left untouched, as tracing
+// them would only point at their declarations.
+func rewrite(filename string, src []byte) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+
+	fmtName := importName(file, "fmt")
+	errorsName := importName(file, "errors")
+	terrName := importName(file, terrPath)
+	if terrName == "" {
+		terrName = "terr"
+	}
+
+	changed := false
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			switch {
+			case fmtName != "" && isCall(call, fmtName, "Errorf"):
+			case errorsName != "" && isCall(call, errorsName, "New") && len(call.Args) == 1:
+				call.Args = newfArgs(call.Args[0])
+			default:
+				return true
+			}
+			call.Fun = &ast.SelectorExpr{
+				X:   ast.NewIdent(terrName),
+				Sel: ast.NewIdent("Newf"),
+			}
+			changed = true
+			return true
+		})
+	}
+	if !changed {
+		return src, false, nil
+	}
+
+	if importName(file, terrPath) == "" {
+		addImport(file, terrPath)
+	}
+	for _, imp := range []struct{ name, path string }{
+		{fmtName, "fmt"},
+		{errorsName, "errors"},
+	} {
+		if imp.name != "" && imp.name != "_" && imp.name != "." && !usesName(file, imp.name) {
+			removeImport(file, imp.path)
+		}
+	}
+	ast.SortImports(fset, file)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, false, err
+	}
+	return buf.Bytes(), true, nil
+}
+
+// importName returns the name under which path is imported in file, or an
+// empty string if it is not imported.
+func importName(file *ast.File, path string) string {
+	for _, spec := range file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p != path {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+		return path[strings.LastIndex(path, "/")+1:]
+	}
+	return ""
+}
+
+// isCall returns whether call is a call to pkg.name.
+func isCall(call *ast.CallExpr, pkg, name string) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == pkg && id.Obj == nil
+}
+
+// newfArgs returns the arguments for a call to terr.Newf producing the same
+// message as a call to errors.New with msg.
+func newfArgs(msg ast.Expr) []ast.Expr {
+	lit, ok := msg.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		// Non-literal messages are formatted as they are.
+		return []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"%s"`}, msg}
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil || !strings.Contains(s, "%") {
+		return []ast.Expr{lit}
+	}
+	escaped := strings.ReplaceAll(s, "%", "%%")
+	if strings.HasPrefix(lit.Value, "`") {
+		lit.Value = "`" + escaped + "`"
+	} else {
+		lit.Value = strconv.Quote(escaped)
+	}
+	return []ast.Expr{lit}
+}
+
+// usesName returns whether name is used as a package qualifier in file.
+func usesName(file *ast.File, name string) bool {
+	used := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name == name && id.Obj == nil {
+				used = true
+			}
+		}
+		return !used
+	})
+	return used
+}
+
+// addImport adds an import for path to file.
+func addImport(file *ast.File, path string) {
+	spec := &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(path)}}
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			if !gen.Lparen.IsValid() {
+				// Turn a single import into a parenthesized one.
+				gen.Lparen = gen.Specs[0].Pos()
+				gen.Rparen = gen.Specs[0].End()
+			}
+			gen.Specs = append(gen.Specs, spec)
+			file.Imports = append(file.Imports, spec)
+			return
+		}
+	}
+	gen := &ast.GenDecl{Tok: token.IMPORT, Specs: []ast.Spec{spec}}
+	file.Decls = append([]ast.Decl{gen}, file.Decls...)
+	file.Imports = append(file.Imports, spec)
+}
+
+// removeImport removes the import for path from file.
+func removeImport(file *ast.File, path string) {
+	for i := 0; i < len(file.Decls); i++ {
+		gen, ok := file.Decls[i].(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		for j, spec := range gen.Specs {
+			if p, _ := strconv.Unquote(spec.(*ast.ImportSpec).Path.Value); p == path {
+				gen.Specs = append(gen.Specs[:j], gen.Specs[j+1:]...)
+				break
+			}
+		}
+		if len(gen.Specs) == 0 {
+			file.Decls = append(file.Decls[:i], file.Decls[i+1:]...)
+			i--
+		}
+	}
+	for i, spec := range file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == path {
+			file.Imports = append(file.Imports[:i], file.Imports[i+1:]...)
+			break
+		}
+	}
+}
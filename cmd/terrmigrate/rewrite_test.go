@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{{
+		name: "errorf and new",
+		src: `package p
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrNotFound = errors.New("not found")
+
+func f(err error, msg string) error {
+	if err != nil {
+		return fmt.Errorf("f: %w", err)
+	}
+	if msg != "" {
+		return errors.New(msg)
+	}
+	return errors.New("100% failed")
+}
+`,
+		want: `package p
+
+import (
+	"errors"
+	"github.com/alnvdl/terr"
+)
+
+var ErrNotFound = errors.New("not found")
+
+func f(err error, msg string) error {
+	if err != nil {
+		return terr.Newf("f: %w", err)
+	}
+	if msg != "" {
+		return terr.Newf("%s", msg)
+	}
+	return terr.Newf("100%% failed")
+}
+`,
+	}, {
+		name: "other uses of fmt and single import",
+		src: `package p
+
+import "fmt"
+
+func f() error {
+	fmt.Println("f")
+	return fmt.Errorf("f")
+}
+`,
+		want: `package p
+
+import (
+	"fmt"
+	"github.com/alnvdl/terr"
+)
+
+func f() error {
+	fmt.Println("f")
+	return terr.Newf("f")
+}
+`,
+	}, {
+		name: "aliased imports",
+		src: `package p
+
+import (
+	stderrors "errors"
+
+	t "github.com/alnvdl/terr"
+)
+
+func f() error {
+	return t.Trace(stderrors.New("f"))
+}
+`,
+		want: `package p
+
+import (
+	t "github.com/alnvdl/terr"
+)
+
+func f() error {
+	return t.Trace(t.Newf("f"))
+}
+`,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, changed, err := rewrite("p.go", []byte(test.src))
+			if err != nil {
+				t.Fatalf("want nil error, got %v", err)
+			}
+			if !changed {
+				t.Fatalf("want changed source")
+			}
+			if string(got) != test.want {
+				t.Fatalf("want:\n%s\ngot:\n%s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestRewriteUnchanged(t *testing.T) {
+	src := "package p\n\nimport \"errors\"\n\nvar ErrNotFound = errors.New(\"not found\")\n"
+	got, changed, err := rewrite("p.go", []byte(src))
+	if err != nil || changed || string(got) != src {
+		t.Fatalf("want unchanged source, got changed=%v err=%v:\n%s", changed, err, got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	src := "package p\n\nimport \"errors\"\n\nfunc f() error {\n\treturn errors.New(\"f\")\n}\n"
+	for _, name := range []string{"p.go", "p_test.go", "testdata/p.go"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755)
+		os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-l", "-w", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("want exit code 0, got %d: %s", code, stderr.String())
+	}
+	if want := filepath.Join(dir, "p.go") + "\n"; stdout.String() != want {
+		t.Fatalf("want listed files %q, got %q", want, stdout.String())
+	}
+	for name, rewritten := range map[string]bool{"p.go": true, "p_test.go": false, "testdata/p.go": false} {
+		b, _ := os.ReadFile(filepath.Join(dir, name))
+		if bytes.Contains(b, []byte("terr.Newf")) != rewritten {
+			t.Fatalf("want %s rewritten=%v, got:\n%s", name, rewritten, b)
+		}
+	}
+}
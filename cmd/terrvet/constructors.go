@@ -0,0 +1,68 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// checkConstructors reports exported error constructors, i.e., exported
+// functions returning only an error, that directly return the result of
+// terr.Newf or terr.Trace. Such errors are all traced to the constructor
+// itself instead of its callers, and TraceSkip should be used instead.
+func checkConstructors(p *pass) {
+	for _, file := range p.files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Body == nil || !fn.Name.IsExported() || !returnsOnlyError(p.info, fn) {
+				continue
+			}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.FuncLit:
+					// Returns in function literals do not return from fn.
+					return false
+				case *ast.ReturnStmt:
+					if len(n.Results) != 1 {
+						return true
+					}
+					call, ok := ast.Unparen(n.Results[0]).(*ast.CallExpr)
+					if !ok {
+						return true
+					}
+					switch called := calledFunc(p.info, call); {
+					case isTerrFunc(called, "Newf"):
+						p.reportf("constructors", call.Pos(), "error constructor %s returns %s directly, tracing errors to itself: use TraceSkip(fmt.Errorf(...), 1) to trace them to its callers", fn.Name.Name, funcName(called))
+					case isTerrFunc(called, "Trace"):
+						p.reportf("constructors", call.Pos(), "error constructor %s returns %s directly, tracing errors to itself: use TraceSkip(err, 1) to trace them to its callers", fn.Name.Name, funcName(called))
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+// returnsOnlyError returns whether fn has a single result of type error.
+func returnsOnlyError(info *types.Info, fn *ast.FuncDecl) bool {
+	obj, ok := info.Defs[fn.Name].(*types.Func)
+	if !ok {
+		return false
+	}
+	results := obj.Type().(*types.Signature).Results()
+	return results.Len() == 1 && types.Identical(results.At(0).Type(), types.Universe.Lookup("error").Type())
+}
+
+// funcName returns the name of fn qualified by its package name, or by its
+// receiver type name for methods.
+func funcName(fn *types.Func) string {
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		t := recv.Type()
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if named, ok := t.(*types.Named); ok {
+			return named.Obj().Name() + "." + fn.Name()
+		}
+	}
+	return fn.Pkg().Name() + "." + fn.Name()
+}
@@ -0,0 +1,200 @@
+// Command terrvet reports suspicious uses of the terr package.
+//
+// Usage:
+//
+//	terrvet [packages]
+//
+// Packages are given as in the go command (e.g., ./...). If no packages are
+// given, the package in the current directory is checked. Diagnostics are
+// printed to standard error, and the exit code is 1 if any were reported.
+//
+// The following checks are performed:
+//
+//	newfverbs    error arguments to terr.Newf formatted with verbs other
+//	             than %w and %v, or not formatted at all
+//	constructors exported error constructors returning terr.Newf or
+//	             terr.Trace directly instead of using terr.TraceSkip
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+)
+
+const terrPath = "github.com/alnvdl/terr"
+
+// diagnostic is a problem found by a check.
+type diagnostic struct {
+	pos     token.Position
+	check   string
+	message string
+}
+
+// pass holds a type-checked package being analyzed.
+type pass struct {
+	fset  *token.FileSet
+	files []*ast.File
+	pkg   *types.Package
+	info  *types.Info
+	diags []diagnostic
+}
+
+// reportf reports a diagnostic for check at pos.
+func (p *pass) reportf(check string, pos token.Pos, format string, args ...any) {
+	p.diags = append(p.diags, diagnostic{p.fset.Position(pos), check, fmt.Sprintf(format, args...)})
+}
+
+// checks are all the checks run by terrvet.
+var checks = []func(*pass){
+	checkNewfVerbs,
+	checkConstructors,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run checks the packages matching patterns, returning the exit code for the
+// process.
+func run(patterns []string, stderr io.Writer) int {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	pkgs, err := listPackages(patterns)
+	if err != nil {
+		fmt.Fprintf(stderr, "terrvet: %v\n", err)
+		return 2
+	}
+
+	fset := token.NewFileSet()
+	imp := importer.ForCompiler(fset, "source", nil)
+	var diags []diagnostic
+	for _, pkg := range pkgs {
+		var paths []string
+		for _, f := range pkg.GoFiles {
+			paths = append(paths, filepath.Join(pkg.Dir, f))
+		}
+		p, err := load(fset, imp, pkg.ImportPath, paths)
+		if err != nil {
+			fmt.Fprintf(stderr, "terrvet: %v\n", err)
+			return 2
+		}
+		for _, check := range checks {
+			check(p)
+		}
+		diags = append(diags, p.diags...)
+	}
+
+	sort.Slice(diags, func(i, j int) bool {
+		a, b := diags[i].pos, diags[j].pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+	wd, _ := os.Getwd()
+	for _, d := range diags {
+		if rel, err := filepath.Rel(wd, d.pos.Filename); err == nil && wd != "" {
+			d.pos.Filename = rel
+		}
+		fmt.Fprintf(stderr, "%s: %s (%s)\n", d.pos, d.message, d.check)
+	}
+	if len(diags) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// listedPackage is a package as listed by go list.
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	GoFiles    []string
+}
+
+// listPackages lists the packages matching patterns with go list.
+func listPackages(patterns []string) ([]listedPackage, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", append([]string{"list", "-json"}, patterns...)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go list: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	var pkgs []listedPackage
+	dec := json.NewDecoder(&stdout)
+	for dec.More() {
+		var pkg listedPackage
+		if err := dec.Decode(&pkg); err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+// load parses and type-checks the files of a package.
+func load(fset *token.FileSet, imp types.Importer, path string, filenames []string) (*pass, error) {
+	p := &pass{
+		fset: fset,
+		info: &types.Info{
+			Types: make(map[ast.Expr]types.TypeAndValue),
+			Uses:  make(map[*ast.Ident]types.Object),
+			Defs:  make(map[*ast.Ident]types.Object),
+		},
+	}
+	for _, filename := range filenames {
+		f, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		p.files = append(p.files, f)
+	}
+	conf := types.Config{Importer: imp}
+	pkg, err := conf.Check(path, fset, p.files, p.info)
+	if err != nil {
+		return nil, err
+	}
+	p.pkg = pkg
+	return p, nil
+}
+
+// calledFunc returns the function or method called by call, if it is
+// statically known.
+func calledFunc(info *types.Info, call *ast.CallExpr) *types.Func {
+	var id *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return nil
+	}
+	fn, _ := info.Uses[id].(*types.Func)
+	return fn
+}
+
+// isTerrFunc returns whether fn is a function or method in the terr package
+// with one of the given names.
+func isTerrFunc(fn *types.Func, names ...string) bool {
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != terrPath {
+		return false
+	}
+	for _, name := range names {
+		if fn.Name() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		pkg      string
+		expected string
+	}{{
+		pkg: "./testdata/newfverbs",
+		expected: "" +
+			"testdata/newfverbs/newfverbs.go:16:26: error argument formatted with %s in terr.Newf: use %w to wrap it or %v to mask it (newfverbs)\n" +
+			"testdata/newfverbs/newfverbs.go:17:24: error argument is not formatted by any verb in terr.Newf (newfverbs)\n" +
+			"testdata/newfverbs/newfverbs.go:20:25: error argument formatted with %q in terr.Newf: use %w to wrap it or %v to mask it (newfverbs)\n",
+	}, {
+		pkg: "./testdata/constructors",
+		expected: "" +
+			"testdata/constructors/constructors.go:13:9: error constructor NewNotFound returns terr.Newf directly, tracing errors to itself: use TraceSkip(fmt.Errorf(...), 1) to trace them to its callers (constructors)\n" +
+			"testdata/constructors/constructors.go:20:9: error constructor WrapNotFound returns terr.Trace directly, tracing errors to itself: use TraceSkip(err, 1) to trace them to its callers (constructors)\n" +
+			"testdata/constructors/constructors.go:26:10: error constructor NewBillingError returns ErrorDomain.Newf directly, tracing errors to itself: use TraceSkip(fmt.Errorf(...), 1) to trace them to its callers (constructors)\n",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.pkg, func(t *testing.T) {
+			var stderr bytes.Buffer
+			code := run([]string{test.pkg}, &stderr)
+			if code != 1 {
+				t.Errorf("expected exit code 1, got %d", code)
+			}
+			if got := stderr.String(); got != test.expected {
+				t.Errorf("unexpected output:\n%s\nexpected:\n%s", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestRunClean(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := run([]string{"."}, &stderr); code != 0 {
+		t.Errorf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+}
+
+func TestParseVerbs(t *testing.T) {
+	tests := []struct {
+		format string
+		verbs  string
+		ok     bool
+	}{
+		{"", "", true},
+		{"no verbs", "", true},
+		{"%w", "w", true},
+		{"%d: %+v", "dv", true},
+		{"100%% %s", "s", true},
+		{"%*.*f %-08x", "**fx", true},
+		{"%[2]s %[1]s", "", false},
+		{"trailing %", "", true},
+	}
+	for _, test := range tests {
+		verbs, ok := parseVerbs(test.format)
+		if ok != test.ok || string(verbs) != test.verbs {
+			t.Errorf("parseVerbs(%q) = %q, %v; expected %q, %v", test.format, string(verbs), ok, test.verbs, test.ok)
+		}
+	}
+}
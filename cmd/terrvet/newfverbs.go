@@ -0,0 +1,80 @@
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strings"
+)
+
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+// checkNewfVerbs reports error arguments to terr.Newf that are formatted with
+// verbs other than %w and %v, which either lose wrapping or produce garbled
+// messages, or that are not formatted at all, which drops them from the
+// message and from Go's wrapped error tree.
+func checkNewfVerbs(p *pass) {
+	for _, file := range p.files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || call.Ellipsis.IsValid() || !isTerrFunc(calledFunc(p.info, call), "Newf") ||
+				len(call.Args) == 0 {
+				return true
+			}
+			tv := p.info.Types[call.Args[0]]
+			if tv.Value == nil || tv.Value.Kind() != constant.String {
+				return true
+			}
+			verbs, ok := parseVerbs(constant.StringVal(tv.Value))
+			if !ok {
+				return true
+			}
+			args := call.Args[1:]
+			for i, arg := range args {
+				t := p.info.Types[arg].Type
+				if t == nil || types.Identical(t, types.Typ[types.UntypedNil]) || !types.Implements(t, errorType) {
+					continue
+				}
+				if i >= len(verbs) {
+					p.reportf("newfverbs", arg.Pos(), "error argument is not formatted by any verb in terr.Newf")
+					continue
+				}
+				if verbs[i] != 'w' && verbs[i] != 'v' {
+					p.reportf("newfverbs", arg.Pos(), "error argument formatted with %%%c in terr.Newf: use %%w to wrap it or %%v to mask it", verbs[i])
+				}
+			}
+			return true
+		})
+	}
+}
+
+// parseVerbs returns the verbs in format, in the order they consume
+// arguments. Arguments consumed by * widths and precisions are represented
+// by '*'. Returns false if format uses explicit argument indexes, which are
+// not supported.
+func parseVerbs(format string) ([]rune, bool) {
+	var verbs []rune
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		for ; i < len(format); i++ {
+			c := format[i]
+			switch {
+			case c == '[':
+				return nil, false
+			case c == '*':
+				verbs = append(verbs, '*')
+			case strings.IndexByte("+-# 0.", c) >= 0 || (c >= '0' && c <= '9'):
+			default:
+				if c != '%' {
+					verbs = append(verbs, rune(c))
+				}
+				goto next
+			}
+		}
+	next:
+	}
+	return verbs, true
+}
@@ -0,0 +1,46 @@
+package constructors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alnvdl/terr"
+)
+
+var ErrNotFound = errors.New("not found")
+
+func NewNotFound(name string) error {
+	return terr.Newf("%w: %s", ErrNotFound, name)
+}
+
+func WrapNotFound(err error) error {
+	if err == nil {
+		return nil
+	}
+	return terr.Trace(err)
+}
+
+var billing = terr.Domain("billing")
+
+func NewBillingError(msg string) error {
+	return (billing.Newf("%s", msg))
+}
+
+func NewGood(name string) error {
+	return terr.TraceSkip(fmt.Errorf("%w: %s", ErrNotFound, name), 1)
+}
+
+func newUnexported(name string) error {
+	return terr.Newf("%w: %s", ErrNotFound, name)
+}
+
+func Lookup(name string) (string, error) {
+	return "", terr.Newf("%w: %s", ErrNotFound, name)
+}
+
+func Deferred() error {
+	f := func() error {
+		return terr.Newf("inner")
+	}
+	return terr.TraceSkip(f(), 1)
+}
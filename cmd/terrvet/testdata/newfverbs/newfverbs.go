@@ -0,0 +1,24 @@
+package newfverbs
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alnvdl/terr"
+)
+
+var errBase = errors.New("base")
+
+func f(n int) []error {
+	return []error{
+		terr.Newf("wrapped: %w", errBase),
+		terr.Newf("masked: %v", errBase),
+		terr.Newf("%d: %s", n, errBase),
+		terr.Newf("dropped", errBase),
+		terr.Newf("%*d %w", 3, n, errBase),
+		terr.Newf("%[1]s", errBase),
+		terr.Newf("100%% %q", errBase),
+		terr.Newf("%s", fmt.Sprint(errBase)),
+		fmt.Errorf("%s", errBase),
+	}
+}
@@ -0,0 +1,176 @@
+package terr
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// childGroup is a set of structurally identical children of a traced error,
+// represented by the first of them.
+type childGroup struct {
+	ErrorTracer
+	count int
+	// rootCause is the fingerprint of the root cause shared by the children
+	// in the group, when grouped by groupByRootCause.
+	rootCause string
+}
+
+// shapeKey identifies the structure of an error tracing tree: the messages,
+// locations, codes, kinds, domains, operations, tags, hints, URLs, stacks,
+// times, metadata and numbers of omitted children of its traced errors.
+// Metadata values are identified by their types and Go-syntax
+// representations.
+type shapeKey [sha256.Size]byte
+
+// groupChildren appends the children to groups, coalescing children that are
+// structurally identical (i.e., that have the same shape key) into a single
+// group. Groups are kept in the order in which their first child appears.
+func groupChildren(groups []childGroup, children []ErrorTracer) []childGroup {
+	index := make(map[shapeKey]int, len(groups)+len(children))
+	for i := len(groups) - 1; i >= 0; i-- {
+		index[treeShape(groups[i].ErrorTracer)] = i
+	}
+	for _, child := range children {
+		key := treeShape(child)
+		if i, ok := index[key]; ok {
+			groups[i].count++
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, childGroup{ErrorTracer: child, count: 1})
+	}
+	return groups
+}
+
+// groupByRootCause appends the children to groups, coalescing children whose
+// root causes have the same fingerprint into a single group, represented by
+// the first of them. The root cause of a child is the first leaf found by
+// following the first children in its error tracing tree. Groups are kept in
+// the order in which their first child appears.
+func groupByRootCause(groups []childGroup, children []ErrorTracer) []childGroup {
+	index := make(map[string]int, len(groups)+len(children))
+	for i := len(groups) - 1; i >= 0; i-- {
+		index[groups[i].rootCause] = i
+	}
+	for _, child := range children {
+		root := child
+		for grandchildren := childrenOf(root); len(grandchildren) > 0; grandchildren = childrenOf(root) {
+			root = grandchildren[0]
+		}
+		fp := treeFingerprint(root)
+		if i, ok := index[fp]; ok {
+			groups[i].count++
+			continue
+		}
+		index[fp] = len(groups)
+		groups = append(groups, childGroup{ErrorTracer: child, count: 1, rootCause: fp})
+	}
+	return groups
+}
+
+// treeShape returns the shape key of the error tracing tree rooted in et.
+// The keys of all its subtrees are computed in a single bottom-up pass and
+// cached in their traced errors, so grouping the children at every level of
+// a tree hashes each node only once. Nodes are kept in an explicit stack, so
+// arbitrarily deep trees cannot overflow the goroutine stack.
+func treeShape(et ErrorTracer) shapeKey {
+	// shapeItem is a node whose key is computed once the keys of its
+	// children are on top of keys, which happens after it is expanded.
+	type shapeItem struct {
+		et       ErrorTracer
+		children int
+		expanded bool
+	}
+	var keys []shapeKey
+	var buf []byte
+	stack := []shapeItem{{et: et}}
+	for len(stack) > 0 {
+		item := stack[len(stack)-1]
+		te, _ := item.et.(*tracedError)
+		if !item.expanded {
+			if key := te.cachedShape(); key != nil {
+				keys = append(keys, *key)
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			children := childrenOf(item.et)
+			stack[len(stack)-1] = shapeItem{item.et, len(children), true}
+			for i := len(children) - 1; i >= 0; i-- {
+				stack = append(stack, shapeItem{et: children[i]})
+			}
+			continue
+		}
+		stack = stack[:len(stack)-1]
+		buf = appendNodeShape(buf[:0], item.et)
+		for _, key := range keys[len(keys)-item.children:] {
+			buf = append(buf, key[:]...)
+		}
+		keys = keys[:len(keys)-item.children]
+		key := shapeKey(sha256.Sum256(buf))
+		if te != nil {
+			te.shape.Store(&key)
+		}
+		keys = append(keys, key)
+	}
+	return keys[0]
+}
+
+// cachedShape returns the cached shape key of the error tracing tree rooted
+// in e, or nil if e is nil or the key was not computed yet.
+func (e *tracedError) cachedShape() *shapeKey {
+	if e == nil {
+		return nil
+	}
+	return e.shape.Load()
+}
+
+// appendNodeShape appends the structure of et, regardless of its children,
+// to dst. Strings and lists are prefixed by their lengths, so different
+// nodes cannot be appended as the same bytes.
+func appendNodeShape(dst []byte, et ErrorTracer) []byte {
+	file, line := et.Location()
+	dst = appendShapeString(dst, et.Error())
+	dst = appendShapeString(dst, file)
+	dst = binary.AppendVarint(dst, int64(line))
+	dst = binary.AppendVarint(dst, int64(OmittedChildren(et)))
+	te, ok := et.(*tracedError)
+	if !ok {
+		return append(dst, 0)
+	}
+	dst = append(dst, 1)
+	dst = appendShapeString(dst, te.code)
+	dst = binary.AppendVarint(dst, int64(te.kind))
+	dst = appendShapeString(dst, te.domain)
+	dst = appendShapeString(dst, opOf(te))
+	dst = appendShapeString(dst, te.url)
+	for _, list := range [...][]string{te.tags, te.hints} {
+		dst = binary.AppendUvarint(dst, uint64(len(list)))
+		for _, s := range list {
+			dst = appendShapeString(dst, s)
+		}
+	}
+	dst = binary.AppendUvarint(dst, uint64(len(te.stack)))
+	for _, pc := range te.stack {
+		dst = binary.AppendUvarint(dst, uint64(pc))
+	}
+	if te.time.IsZero() {
+		dst = append(dst, 0)
+	} else {
+		dst = append(dst, 1)
+		dst = binary.AppendVarint(dst, te.time.Unix())
+		dst = binary.AppendUvarint(dst, uint64(te.time.Nanosecond()))
+	}
+	dst = binary.AppendUvarint(dst, uint64(len(te.metadata)))
+	for _, md := range te.metadata {
+		dst = appendShapeString(dst, md.key)
+		dst = appendShapeString(dst, fmt.Sprintf("%T %#v", md.value, md.value))
+	}
+	return dst
+}
+
+// appendShapeString appends s to dst, prefixed by its length.
+func appendShapeString(dst []byte, s string) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(s)))
+	return append(dst, s...)
+}
@@ -0,0 +1,106 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestCoalesce(t *testing.T) {
+	file, line := getLocation(0)
+	var errs []any
+	for i := 0; i < 3; i++ {
+		errs = append(errs, terr.Trace(terr.Newf("failed")))
+	}
+	errs = append(errs, terr.Newf("other"))
+	err := terr.Newf("%v %v %v %v", errs...)
+
+	assertEquals(t, len(terr.TraceTree(err).Children()), 4)
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("failed failed failed other @ %s:%d", file, line+6),
+		fmt.Sprintf("\tfailed @ %s:%d (x3)", file, line+3),
+		fmt.Sprintf("\t\tfailed @ %s:%d", file, line+3),
+		fmt.Sprintf("\tother @ %s:%d", file, line+5),
+	}, "\n"))
+
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(b), fmt.Sprintf(`{"message":"failed failed failed other","file":%q,"line":%d,"children":[`+
+		`{"message":"failed","file":%q,"line":%d,"count":3,"children":[{"message":"failed","file":%q,"line":%d}]},`+
+		`{"message":"other","file":%q,"line":%d}]}`,
+		file, line+6, file, line+3, file, line+3, file, line+5))
+}
+
+func TestCoalesceDifferentMessages(t *testing.T) {
+	var errs []any
+	for i := 0; i < 3; i++ {
+		errs = append(errs, terr.Newf("failed %d", i))
+	}
+	err := terr.Newf("%v %v %v", errs...)
+	assertEquals(t, strings.Count(fmt.Sprintf("%@", err), "\n"), 3)
+}
+
+func TestGroupByRootCause(t *testing.T) {
+	file, line := getLocation(0)
+	diskFull := terr.Newf("disk full")
+	timeout := terr.Newf("timeout")
+	var errs []error
+	for i := 0; i < 5; i++ {
+		errs = append(errs, terr.Newf("item %d: %w", i, diskFull))
+	}
+	errs = append(errs, terr.Newf("item 5: %w", timeout))
+	err := terr.Trace(errors.New("batch failed"), terr.WithChildren(treesOf(errs)...))
+
+	assertEquals(t, fmt.Sprintf("%#@", err), strings.Join([]string{
+		fmt.Sprintf("batch failed @ %s:%d", file, line+8),
+		fmt.Sprintf("\titem 0: disk full @ %s:%d (x5, same root cause)", file, line+5),
+		fmt.Sprintf("\t\tdisk full @ %s:%d", file, line+1),
+		fmt.Sprintf("\titem 5: timeout @ %s:%d", file, line+7),
+		fmt.Sprintf("\t\ttimeout @ %s:%d", file, line+2),
+	}, "\n"))
+	assertEquals(t, strings.Count(fmt.Sprintf("%@", err), "\n"), 12)
+}
+
+func TestCoalesceWide(t *testing.T) {
+	file, line := getLocation(0)
+	children := make([]terr.ErrorTracer, 10000)
+	for i := range children {
+		children[i] = terr.TraceTree(terr.Trace(terr.Newf("failed")))
+	}
+	err := terr.Trace(errors.New("batch failed"), terr.WithChildren(children...))
+
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("batch failed @ %s:%d", file, line+5),
+		fmt.Sprintf("\tfailed @ %s:%d (x10000)", file, line+3),
+		fmt.Sprintf("\t\tfailed @ %s:%d", file, line+3),
+	}, "\n"))
+}
+
+func TestCoalesceMetadataTypes(t *testing.T) {
+	file, line := getLocation(0)
+	var children []terr.ErrorTracer
+	for _, value := range []any{1, int64(1), 1} {
+		children = append(children, terr.TraceTree(terr.NewfWith([]terr.TraceOption{terr.WithMetadata("n", value)}, "failed")))
+	}
+	err := terr.Trace(errors.New("batch failed"), terr.WithChildren(children...))
+
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("batch failed @ %s:%d", file, line+5),
+		fmt.Sprintf("\tfailed @ %s:%d [n=1] (x2)", file, line+3),
+		fmt.Sprintf("\tfailed @ %s:%d [n=1]", file, line+3),
+	}, "\n"))
+}
+
+func treesOf(errs []error) []terr.ErrorTracer {
+	trees := make([]terr.ErrorTracer, len(errs))
+	for i, err := range errs {
+		trees[i] = terr.TraceTree(err)
+	}
+	return trees
+}
@@ -0,0 +1,47 @@
+package terr
+
+// WithCode sets a code for the traced error, which can be used to classify
+// errors in a stable manner, regardless of their messages (e.g., for metrics
+// or API responses). If the traced error has a domain, the code is prefixed
+// by it.
+func WithCode(code string) TraceOption {
+	return func(e *tracedError) {
+		e.code = code
+	}
+}
+
+// Code returns the code of the first traced error with a code in the error
+// tracing tree for err, as returned by TraceTree, found by a depth-first search
+// starting at its root. Returns an empty string if err has no traced error or
+// if no code is set.
+func Code(err error) string {
+	et := TraceTree(err)
+	if et == nil {
+		return ""
+	}
+	return treeCode(et)
+}
+
+// treeCode returns the first code found in the error tracing tree rooted in
+// et.
+func treeCode(et ErrorTracer) string {
+	var code string
+	walkTree(et, func(et ErrorTracer) bool {
+		if te, ok := et.(*tracedError); ok && te.code != "" {
+			code = te.fullCode()
+			return false
+		}
+		return true
+	})
+	return code
+}
+
+// IsCode returns whether any traced error in the error tracing tree for err
+// has the given code, with or without its domain prefix. Unlike Code, all
+// error tracing trees in the Go error tree of err are considered, not only
+// the nearest one.
+func IsCode(err error, code string) bool {
+	return anyTraced(err, func(te *tracedError) bool {
+		return te.code != "" && (te.code == code || te.fullCode() == code)
+	})
+}
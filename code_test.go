@@ -0,0 +1,38 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestCode(t *testing.T) {
+	err := terr.Trace(terr.Newf("fail"), terr.WithCode("inner"))
+	wrapped := terr.Newf("wrapped: %w", err)
+	outer := terr.Trace(wrapped, terr.WithCode("outer"))
+
+	assertEquals(t, terr.Code(err), "inner")
+	assertEquals(t, terr.Code(wrapped), "inner")
+	assertEquals(t, terr.Code(outer), "outer")
+	assertEquals(t, terr.Code(terr.Newf("fail")), "")
+	assertEquals(t, terr.Code(errors.New("fail")), "")
+	assertEquals(t, terr.Code(nil), "")
+}
+
+func TestIsCode(t *testing.T) {
+	err := terr.Trace(terr.Newf("fail"), terr.WithCode("inner"))
+	outer := terr.Trace(err, terr.WithCode("outer"), terr.WithDomain("billing"))
+	wrapped := fmt.Errorf("wrapped: %w", outer)
+
+	assertEquals(t, terr.IsCode(wrapped, "inner"), true)
+	assertEquals(t, terr.IsCode(wrapped, "outer"), true)
+	assertEquals(t, terr.IsCode(wrapped, "billing.outer"), true)
+	assertEquals(t, terr.IsCode(wrapped, "other"), false)
+	assertEquals(t, terr.IsCode(wrapped, ""), false)
+	assertEquals(t, terr.IsCode(errors.New("fail"), "inner"), false)
+	assertEquals(t, terr.IsCode(nil, "inner"), false)
+}
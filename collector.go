@@ -0,0 +1,73 @@
+package terr
+
+import (
+	"sync"
+)
+
+// Collector accumulates errors, possibly from multiple goroutines, so they
+// can be returned as the children of a single traced error. Traced errors
+// themselves are immutable, so a Collector must be used instead of adding
+// children to existing traced errors. The zero value is ready to use, and a
+// Collector must not be copied after first use.
+type Collector struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// Add records err in c. Errors that are not traced errors are traced where
+// Add is called. Nil errors are ignored. It is safe to call Add concurrently.
+func (c *Collector) Add(err error) {
+	if err == nil {
+		return
+	}
+	if _, ok := err.(*tracedError); !ok {
+		if te := newTracedError(err, []any{err}, 0, nil); te != nil {
+			err = te
+		}
+	}
+	c.mu.Lock()
+	c.errs = append(c.errs, err)
+	c.mu.Unlock()
+}
+
+// Len returns the number of errors recorded in c.
+func (c *Collector) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.errs)
+}
+
+// Err returns a traced error with message msg including all errors recorded
+// in c so far as children, in the order they were added, or nil if no errors
+// were recorded. The returned error wraps the recorded errors, so they can be
+// matched with errors.Is and errors.As.
+func (c *Collector) Err(msg string) error {
+	c.mu.Lock()
+	errs := append([]error(nil), c.errs...)
+	c.mu.Unlock()
+	if len(errs) == 0 {
+		return nil
+	}
+	err := &collectedError{msg: msg, errs: errs}
+	if te := newTracedError(err, []any{err}, 0, nil); te != nil {
+		return te
+	}
+	return err
+}
+
+// collectedError is the error returned by Collector.Err, combining the
+// recorded errors.
+type collectedError struct {
+	msg  string
+	errs []error
+}
+
+// Error implements the error interface.
+func (e *collectedError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the recorded errors for use with errors.Is and errors.As.
+func (e *collectedError) Unwrap() []error {
+	return e.errs
+}
@@ -0,0 +1,53 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestCollector(t *testing.T) {
+	var c terr.Collector
+	assertErrorIsNil(t, c.Err("failed"))
+
+	file, line := getLocation(0)
+	traced := terr.Newf("traced")
+	plain := errors.New("plain")
+	c.Add(traced)
+	c.Add(nil)
+	c.Add(plain)
+	err := c.Err("2 operations failed")
+
+	assertEquals(t, c.Len(), 2)
+	assertEquals(t, err.Error(), "2 operations failed")
+	assertEquals(t, errors.Is(err, traced), true)
+	assertEquals(t, errors.Is(err, plain), true)
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("2 operations failed @ %s:%d", file, line+6),
+		fmt.Sprintf("\ttraced @ %s:%d", file, line+1),
+		fmt.Sprintf("\tplain @ %s:%d", file, line+5),
+	}, "\n"))
+}
+
+func TestCollectorConcurrent(t *testing.T) {
+	var c terr.Collector
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.Add(terr.Newf("fail %d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	err := c.Err("failed")
+	assertEquals(t, c.Len(), 100)
+	assertEquals(t, len(terr.TraceTree(err).Children()), 100)
+}
@@ -0,0 +1,141 @@
+// Package compat implements functions with the same signatures as the ones
+// in github.com/pkg/errors, but returning traced errors, so code bases using
+// that package can migrate to terr by replacing an import path:
+//
+//	import errors "github.com/alnvdl/terr/compat"
+//
+// The location of the traced errors returned by this package is the location
+// of their callers. Instead of stack traces, the error tracing tree can be
+// printed with the %@ verb.
+package compat
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alnvdl/terr"
+)
+
+// withMessage annotates an error with a message, while still wrapping it.
+type withMessage struct {
+	cause error
+	msg   string
+}
+
+func (w *withMessage) Error() string {
+	return w.msg + ": " + w.cause.Error()
+}
+
+// Cause returns the annotated error.
+func (w *withMessage) Cause() error {
+	return w.cause
+}
+
+// Unwrap returns the annotated error for use with errors.Unwrap.
+func (w *withMessage) Unwrap() error {
+	return w.cause
+}
+
+// traced returns a traced error for err located at the caller of the
+// function calling traced, including any traced errors in children as
+// children.
+func traced(err error, children ...any) error {
+	var trees []terr.ErrorTracer
+	for _, child := range children {
+		if child, ok := child.(error); ok {
+			trees = append(trees, terr.TraceTree(child))
+		}
+	}
+	return terr.TraceSkip(err, 2, terr.WithChildren(trees...))
+}
+
+// New returns a traced error with the given message.
+func New(message string) error {
+	return traced(errors.New(message))
+}
+
+// Errorf works exactly like fmt.Errorf, but returns a traced error including
+// all traced errors in args as children.
+func Errorf(format string, args ...any) error {
+	return traced(fmt.Errorf(format, args...), args...)
+}
+
+// WithStack returns a traced error for err. Returns nil if err is nil.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	return terr.TraceSkip(err, 1)
+}
+
+// Wrap returns a traced error annotating err with message. Returns nil if err
+// is nil.
+func Wrap(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return traced(&withMessage{err, message}, err)
+}
+
+// Wrapf returns a traced error annotating err with the formatted message.
+// Returns nil if err is nil.
+func Wrapf(err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return traced(&withMessage{err, fmt.Sprintf(format, args...)}, err)
+}
+
+// WithMessage annotates err with message. Returns nil if err is nil. Since
+// all errors returned by this package are traced, it is equivalent to Wrap.
+func WithMessage(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return traced(&withMessage{err, message}, err)
+}
+
+// WithMessagef annotates err with the formatted message. Returns nil if err
+// is nil. Since all errors returned by this package are traced, it is
+// equivalent to Wrapf.
+func WithMessagef(err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return traced(&withMessage{err, fmt.Sprintf(format, args...)}, err)
+}
+
+// Cause returns the underlying cause of err, if possible. An error has a
+// cause if it implements the Cause() error method, which is the case for
+// errors returned by this package and for all traced errors.
+func Cause(err error) error {
+	type causer interface {
+		Cause() error
+	}
+	for err != nil {
+		c, ok := err.(causer)
+		if !ok {
+			break
+		}
+		err = c.Cause()
+	}
+	return err
+}
+
+// Is reports whether any error in the chain of err matches target. It is
+// equivalent to errors.Is.
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+// As finds the first error in the chain of err that matches target. It is
+// equivalent to errors.As.
+func As(err error, target any) bool {
+	return errors.As(err, target)
+}
+
+// Unwrap returns the result of calling the Unwrap method on err, if any. It
+// is equivalent to errors.Unwrap.
+func Unwrap(err error) error {
+	return errors.Unwrap(err)
+}
@@ -0,0 +1,87 @@
+//go:build !terr_noop
+
+package compat_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+	"github.com/alnvdl/terr/compat"
+)
+
+func getLocation(depth int) (string, int) {
+	_, file, line, _ := runtime.Caller(depth + 1)
+	return file, line
+}
+
+func assertEquals[T comparable](t *testing.T, got, want T) {
+	if got != want {
+		t.Fatalf("want %#v got %#v", want, got)
+	}
+}
+
+var errSentinel = errors.New("sentinel")
+
+func TestWrap(t *testing.T) {
+	file, line := getLocation(0)
+	err := compat.WithStack(errSentinel)
+	wrapped := compat.Wrap(err, "wrapped")
+	wrappedf := compat.Wrapf(wrapped, "wrapped %d", 2)
+	msg := compat.WithMessage(wrappedf, "msg")
+	msgf := compat.WithMessagef(msg, "msg %d", 2)
+
+	assertEquals(t, msgf.Error(), "msg 2: msg: wrapped 2: wrapped: sentinel")
+	assertEquals(t, compat.Cause(msgf), errSentinel)
+	assertEquals(t, compat.Is(msgf, errSentinel), true)
+	assertEquals(t, compat.Unwrap(msgf), msg)
+	assertEquals(t, fmt.Sprintf("%@", msgf), strings.Join([]string{
+		fmt.Sprintf("msg 2: msg: wrapped 2: wrapped: sentinel @ %s:%d", file, line+5),
+		fmt.Sprintf("\tmsg: wrapped 2: wrapped: sentinel @ %s:%d", file, line+4),
+		fmt.Sprintf("\t\twrapped 2: wrapped: sentinel @ %s:%d", file, line+3),
+		fmt.Sprintf("\t\t\twrapped: sentinel @ %s:%d", file, line+2),
+		fmt.Sprintf("\t\t\t\tsentinel @ %s:%d", file, line+1),
+	}, "\n"))
+}
+
+func TestNewErrorf(t *testing.T) {
+	file, line := getLocation(0)
+	err := compat.New("fail")
+	errf := compat.Errorf("failed: %w", err)
+
+	assertEquals(t, errf.Error(), "failed: fail")
+	assertEquals(t, compat.Unwrap(errf), err)
+	assertEquals(t, fmt.Sprintf("%@", errf), strings.Join([]string{
+		fmt.Sprintf("failed: fail @ %s:%d", file, line+2),
+		fmt.Sprintf("\tfail @ %s:%d", file, line+1),
+	}, "\n"))
+}
+
+func TestNil(t *testing.T) {
+	for _, err := range []error{
+		compat.WithStack(nil),
+		compat.Wrap(nil, "msg"),
+		compat.Wrapf(nil, "msg"),
+		compat.WithMessage(nil, "msg"),
+		compat.WithMessagef(nil, "msg"),
+		compat.Cause(nil),
+	} {
+		if err != nil {
+			t.Fatalf("want nil error, got %#v", err)
+		}
+	}
+}
+
+type customError struct{}
+
+func (*customError) Error() string { return "custom" }
+
+func TestAsCause(t *testing.T) {
+	err := compat.Wrap(terr.Trace(&customError{}), "wrapped")
+	var ce *customError
+	assertEquals(t, compat.As(err, &ce), true)
+	assertEquals(t, compat.Cause(err), error(ce))
+}
@@ -0,0 +1,134 @@
+package terr
+
+import (
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Config bundles settings for creating traced errors. It is meant to be built
+// once and shared through a helper, so all traced errors created in a code
+// base follow the same conventions:
+//
+//	var traceConfig = terr.Config{
+//		Location: func(file string) string {
+//			return strings.TrimPrefix(file, "/build/src/")
+//		},
+//		Tags: []string{"storage"},
+//	}
+//
+//	func fail(err error) error {
+//		return terr.TraceSkip(err, 1, terr.WithConfig(traceConfig))
+//	}
+type Config struct {
+	// Skip is the number of additional stack frames to skip when detecting
+	// the location of traced errors.
+	Skip int
+	// Location, if set, transforms the file path recorded as the location of
+	// traced errors, e.g., to trim a build-specific prefix.
+	Location func(file string) string
+	// Tags are added to traced errors, as if WithTags was used.
+	Tags []string
+	// IDs is whether unique IDs are assigned to traced errors, as if WithID
+	// was used.
+	IDs bool
+	// AutoOp is whether the operation of traced errors is set to the name
+	// of the function where they were created, as if WithAutoOp was used.
+	AutoOp bool
+	// Redact is whether the messages and metadata of traced errors must be
+	// redacted in structured output, as if RedactMessages was used when
+	// exporting them. This affects JSON and slog output, but not the text
+	// representation of error tracing trees.
+	Redact bool
+	// SampleRate, if between 0 and 1 (exclusive), is the fraction of calls
+	// creating traced errors. In the remaining calls, Newf degrades to
+	// fmt.Errorf and Trace and TraceSkip return their error unchanged, as
+	// when the rate limit set with SetRateLimit is exceeded. Other values
+	// disable sampling.
+	SampleRate float64
+	// Stack is whether the full call stack is captured for traced errors, so
+	// it is included in JSON output along with their locations. Capturing
+	// stacks is much more expensive than capturing locations, so stacks are
+	// never captured in the reduced-footprint mode selected with the
+	// terr_tiny build tag.
+	Stack bool
+	// StackDepth is the maximum number of frames captured when Stack is set.
+	// Zero or negative values capture up to 64 frames.
+	StackDepth int
+}
+
+// defaults is the Config set with SetDefaults.
+var defaults atomic.Pointer[Config]
+
+// SetDefaults sets c as the Config applied to all traced errors created from
+// now on, so applications can configure tracing once at startup instead of
+// passing options in every call. A Config given with WithConfig replaces the
+// defaults for that traced error, except for tags, which are combined.
+// Passing a zero Config resets the defaults.
+func SetDefaults(c Config) {
+	defaults.Store(&c)
+}
+
+// applyDefaults applies the Config set with SetDefaults to e, if any.
+func (e *tracedError) applyDefaults() {
+	if d := defaults.Load(); d != nil {
+		e.tags = append(e.tags, d.Tags...)
+		e.redacted = d.Redact
+		e.autoOp = d.AutoOp
+		if d.IDs {
+			e.id = newULID(time.Now())
+		}
+		e.config = d
+	}
+}
+
+// WithConfig applies all settings in c to the traced error. If WithConfig is
+// given more than once, only the last Config applies, but tags from all of
+// them are added.
+func WithConfig(c Config) TraceOption {
+	return func(e *tracedError) {
+		e.tags = append(e.tags, c.Tags...)
+		e.redacted = c.Redact
+		if c.IDs && e.id == "" {
+			e.id = newULID(time.Now())
+		}
+		if c.AutoOp {
+			e.autoOp = true
+		}
+		e.config = &c
+	}
+}
+
+// applyConfig applies the location and sampling settings of the Config set
+// with WithConfig or SetDefaults to e, skipping a number of stack frames to
+// detect the location of the caller, with 0 identifying the caller of
+// applyConfig. Returns false if e was not sampled and must be discarded.
+func (e *tracedError) applyConfig(skip int) bool {
+	c := e.config
+	e.config = nil
+	if c.SampleRate > 0 && c.SampleRate < 1 && rand.Float64() >= c.SampleRate {
+		return false
+	}
+	if c.Skip > 0 {
+		var pcs [1]uintptr
+		if runtime.Callers(2+skip+c.Skip, pcs[:]) > 0 {
+			e.pc = pcs[0]
+		}
+	}
+	if c.Stack && !tiny {
+		e.stack = captureStack(1+skip+c.Skip, c.StackDepth)
+	}
+	if c.Location != nil {
+		loc := e.resolveLocation()
+		e.loc.Store(&location{c.Location(loc.file), loc.line, loc.function})
+	}
+	return true
+}
+
+// isRedacted returns whether et must be redacted in structured output due to
+// its Config.
+func isRedacted(et ErrorTracer) bool {
+	te, ok := et.(*tracedError)
+	return ok && te.redacted
+}
@@ -0,0 +1,123 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestWithConfig(t *testing.T) {
+	cfg := terr.Config{
+		Skip:     1,
+		Location: filepath.Base,
+		Tags:     []string{"storage"},
+	}
+	fail := func(err error) error {
+		return terr.Trace(err, terr.WithConfig(cfg), terr.WithTags("extra"))
+	}
+
+	file, line := getLocation(0)
+	err := fail(terr.Newf("fail"))
+
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("fail @ %s:%d", filepath.Base(file), line+1),
+		fmt.Sprintf("\tfail @ %s:%d", file, line+1),
+	}, "\n"))
+	assertEquals(t, strings.Join(terr.Tags(terr.TraceTree(err)), ","), "storage,extra")
+}
+
+func TestWithConfigTraceSkip(t *testing.T) {
+	cfg := terr.Config{Skip: 1}
+	fail := func() error {
+		return terr.TraceSkip(terr.Newf("fail"), 1, terr.WithConfig(cfg))
+	}
+	wrapper := func() error {
+		return fail()
+	}
+
+	file, line := getLocation(0)
+	err := wrapper()
+
+	gotFile, gotLine := terr.TraceTree(err).Location()
+	assertEquals(t, gotFile, file)
+	assertEquals(t, gotLine, line+1)
+}
+
+func TestWithConfigRedact(t *testing.T) {
+	err := terr.Trace(terr.Newf("secret"),
+		terr.WithCode("failed"),
+		terr.WithMetadata("user", "alice"),
+		terr.WithConfig(terr.Config{Redact: true}))
+
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	var decoded struct {
+		Message  string         `json:"message"`
+		Metadata map[string]any `json:"metadata"`
+		Children []struct {
+			Message string `json:"message"`
+		} `json:"children"`
+	}
+	assertErrorIsNil(t, json.Unmarshal(b, &decoded))
+	assertEquals(t, decoded.Message, "failed")
+	assertEquals(t, decoded.Metadata == nil, true)
+	// The child was not created with a redacting Config.
+	assertEquals(t, decoded.Children[0].Message, "secret")
+	// The text representation is not redacted.
+	assertEquals(t, strings.HasPrefix(fmt.Sprintf("%@", err), "secret @ "), true)
+}
+
+func TestSetDefaults(t *testing.T) {
+	terr.SetDefaults(terr.Config{
+		Location: filepath.Base,
+		Tags:     []string{"app"},
+	})
+	defer terr.SetDefaults(terr.Config{})
+
+	_, line := getLocation(0)
+	err := terr.Newf("fail")
+	traced := terr.Trace(err, terr.WithTags("extra"))
+	overridden := terr.Trace(err, terr.WithConfig(terr.Config{Tags: []string{"own"}}))
+
+	file, gotLine := terr.TraceTree(err).Location()
+	assertEquals(t, file, "config_test.go")
+	assertEquals(t, gotLine, line+1)
+	assertEquals(t, strings.Join(terr.Tags(terr.TraceTree(err)), ","), "app")
+	assertEquals(t, strings.Join(terr.Tags(terr.TraceTree(traced)), ","), "app,extra")
+	assertEquals(t, strings.Join(terr.Tags(terr.TraceTree(overridden)), ","), "app,own")
+	file, _ = terr.TraceTree(overridden).Location()
+	assertEquals(t, filepath.IsAbs(file), true)
+
+	terr.SetDefaults(terr.Config{})
+	file, _ = terr.TraceTree(terr.Newf("fail")).Location()
+	assertEquals(t, filepath.IsAbs(file), true)
+	assertEquals(t, terr.Tags(terr.TraceTree(terr.Newf("fail"))) == nil, true)
+}
+
+func TestSampleRate(t *testing.T) {
+	terr.SetDefaults(terr.Config{SampleRate: 0.5})
+	defer terr.SetDefaults(terr.Config{})
+
+	traced := 0
+	for i := 0; i < 1000; i++ {
+		err := terr.Newf("fail")
+		if terr.TraceTree(err) != nil {
+			traced++
+		}
+		assertEquals(t, err.Error(), "fail")
+	}
+	assertEquals(t, traced > 300 && traced < 700, true)
+
+	// A per-call Config replaces the defaults.
+	for i := 0; i < 100; i++ {
+		err := terr.Trace(errors.New("fail"), terr.WithConfig(terr.Config{}))
+		assertEquals(t, terr.TraceTree(err) != nil, true)
+	}
+}
@@ -0,0 +1,147 @@
+package terr
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+var spanContextFunc atomic.Pointer[func(context.Context) (string, string)]
+
+// contextKey is a context key whose value is recorded by WithContext under a
+// metadata key.
+type contextKey struct {
+	name string
+	key  any
+}
+
+var contextKeys atomic.Pointer[[]contextKey]
+
+// WithContext records the state of ctx in the traced error metadata: the
+// time remaining until the ctx deadline, if any, under the
+// "deadline_remaining" key (negative if the deadline has passed), and the
+// ctx error, if it is already done, under the "context_error" key. This helps
+// understanding how much time budget was left when timeout-related errors
+// occurred. If a function was registered with SetSpanContext, the trace and
+// span IDs it extracts from ctx are also recorded, under the "trace_id" and
+// "span_id" keys. Values of the context keys registered with SetContextKeys
+// are recorded as well. If ctx was returned by Inherit, the traced error for
+// the location where it was called is included as a child, unless a traced
+// error wrapped by this one already includes it, so it is not repeated at
+// every layer of errors created with the same ctx.
+func WithContext(ctx context.Context) TraceOption {
+	return func(e *tracedError) {
+		if spawn, ok := ctx.Value(spawnKey{}).(*tracedError); ok && !hasSpawn(e, spawn) {
+			e.addChild(spawn)
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			e.setMetadata("deadline_remaining", time.Until(deadline))
+		}
+		if err := ctx.Err(); err != nil {
+			e.setMetadata("context_error", err.Error())
+		}
+		if fn := spanContextFunc.Load(); fn != nil {
+			traceID, spanID := (*fn)(ctx)
+			if traceID != "" {
+				e.setMetadata("trace_id", traceID)
+			}
+			if spanID != "" {
+				e.setMetadata("span_id", spanID)
+			}
+		}
+		if keys := contextKeys.Load(); keys != nil {
+			for _, k := range *keys {
+				if v := ctx.Value(k.key); v != nil {
+					e.setMetadata(k.name, v)
+				}
+			}
+		}
+	}
+}
+
+// SetSpanContext registers fn to extract the active distributed tracing
+// trace and span IDs from a context, so WithContext can record them and error
+// reports can be joined with distributed traces (e.g., from OpenTelemetry)
+// without terr depending on any tracing library. fn should return empty
+// strings if ctx has no active span. Passing nil disables the extraction.
+func SetSpanContext(fn func(ctx context.Context) (traceID, spanID string)) {
+	if fn == nil {
+		spanContextFunc.Store(nil)
+		return
+	}
+	spanContextFunc.Store(&fn)
+}
+
+// SetContextKeys registers context keys whose values WithContext records in
+// the traced error metadata, under the corresponding names in keys. This way,
+// values like request IDs set by middleware are attached to all errors traced
+// with WithContext while handling a request, linking client reports, logs and
+// error tracing trees:
+//
+//	terr.SetContextKeys(map[string]any{"request_id": httphandler.RequestIDKey{}})
+//
+// Values are recorded sorted by their names. Passing nil or an empty map
+// unregisters all keys.
+func SetContextKeys(keys map[string]any) {
+	if len(keys) == 0 {
+		contextKeys.Store(nil)
+		return
+	}
+	list := make([]contextKey, 0, len(keys))
+	for name, key := range keys {
+		list = append(list, contextKey{name, key})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	contextKeys.Store(&list)
+}
+
+// spawnKey is the context key under which Inherit stores the location where
+// goroutines were spawned.
+type spawnKey struct{}
+
+// hasSpawn returns whether spawn is a child of e or of any traced error below
+// it. Nodes are kept in an explicit stack, so arbitrarily deep trees cannot
+// overflow the goroutine stack.
+func hasSpawn(e, spawn *tracedError) bool {
+	stack := []ErrorTracer{e}
+	for len(stack) > 0 {
+		et := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, child := range childrenOf(et) {
+			if child == ErrorTracer(spawn) {
+				return true
+			}
+			stack = append(stack, child)
+		}
+	}
+	return false
+}
+
+// errSpawned is the error traced by Inherit.
+var errSpawned = errors.New("goroutine spawned")
+
+// Inherit returns a copy of ctx recording the location where Inherit is
+// called, so traced errors created with WithContext(ctx) include a
+// "goroutine spawned" child located there. It is meant to be called where
+// work is handed to other goroutines (e.g., submitted to worker pools), so
+// errors show where the work was submitted as well as where it failed:
+//
+//	ctx := terr.Inherit(ctx)
+//	pool.Submit(func() { results <- process(ctx, job) })
+//
+// If ctx was already returned by Inherit, the previous location is included
+// as a child of the new one, so the whole chain of spawning goroutines is
+// recorded.
+func Inherit(ctx context.Context) context.Context {
+	var children []any
+	if parent, ok := ctx.Value(spawnKey{}).(*tracedError); ok {
+		children = []any{parent}
+	}
+	te := newTracedError(errSpawned, children, 0, nil)
+	if te == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spawnKey{}, te)
+}
@@ -0,0 +1,108 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestWithContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	err := terr.Trace(terr.Newf("fail"), terr.WithContext(ctx))
+	md := terr.Metadata(terr.TraceTree(err))
+	remaining := md["deadline_remaining"].(time.Duration)
+	assertEquals(t, remaining > 59*time.Minute && remaining <= time.Hour, true)
+	_, ok := md["context_error"]
+	assertEquals(t, ok, false)
+
+	cancel()
+	err = terr.Trace(terr.Newf("fail"), terr.WithContext(ctx))
+	md = terr.Metadata(terr.TraceTree(err))
+	assertEquals(t, md["context_error"], any("context canceled"))
+	assertEquals(t, strings.Contains(terr.Sprint(err), `context_error="context canceled"`), true)
+
+	err = terr.Trace(terr.Newf("fail"), terr.WithContext(context.Background()))
+	assertEquals(t, terr.Metadata(terr.TraceTree(err)) == nil, true)
+}
+
+type spanKey struct{}
+
+func TestSetSpanContext(t *testing.T) {
+	terr.SetSpanContext(func(ctx context.Context) (string, string) {
+		ids, _ := ctx.Value(spanKey{}).([2]string)
+		return ids[0], ids[1]
+	})
+	defer terr.SetSpanContext(nil)
+
+	ctx := context.WithValue(context.Background(), spanKey{}, [2]string{"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"})
+	err := terr.Trace(terr.Newf("fail"), terr.WithContext(ctx))
+	md := terr.Metadata(terr.TraceTree(err))
+	assertEquals(t, md["trace_id"], any("4bf92f3577b34da6a3ce929d0e0e4736"))
+	assertEquals(t, md["span_id"], any("00f067aa0ba902b7"))
+
+	err = terr.Trace(terr.Newf("fail"), terr.WithContext(context.Background()))
+	assertEquals(t, terr.Metadata(terr.TraceTree(err)) == nil, true)
+
+	terr.SetSpanContext(nil)
+	err = terr.Trace(terr.Newf("fail"), terr.WithContext(ctx))
+	assertEquals(t, terr.Metadata(terr.TraceTree(err)) == nil, true)
+}
+
+func TestInherit(t *testing.T) {
+	file, line := getLocation(0)
+	submit := func(ctx context.Context) context.Context { return terr.Inherit(ctx) }
+	ctx := submit(submit(context.Background()))
+
+	errs := make(chan error)
+	go func() { errs <- terr.Trace(terr.Newf("fail"), terr.WithContext(ctx)) }()
+	err := <-errs
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("fail @ %s:%d", file, line+5),
+		fmt.Sprintf("\tfail @ %s:%d", file, line+5),
+		fmt.Sprintf("\tgoroutine spawned @ %s:%d", file, line+1),
+		fmt.Sprintf("\t\tgoroutine spawned @ %s:%d", file, line+1),
+	}, "\n"))
+}
+
+func TestInheritLayers(t *testing.T) {
+	file, line := getLocation(0)
+	ctx := terr.Inherit(context.Background())
+	err := terr.NewfWith([]terr.TraceOption{terr.WithContext(ctx)}, "fail")
+	err = terr.Trace(err, terr.WithContext(ctx))
+	err = terr.Trace(err, terr.WithContext(ctx))
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("fail @ %s:%d", file, line+4),
+		fmt.Sprintf("\tfail @ %s:%d", file, line+3),
+		fmt.Sprintf("\t\tfail @ %s:%d", file, line+2),
+		fmt.Sprintf("\t\t\tgoroutine spawned @ %s:%d", file, line+1),
+	}, "\n"))
+}
+
+type tenantKey struct{}
+
+func TestSetContextKeys(t *testing.T) {
+	terr.SetContextKeys(map[string]any{"tenant": tenantKey{}, "request_id": spanKey{}})
+	defer terr.SetContextKeys(nil)
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	ctx = context.WithValue(ctx, spanKey{}, "req-1")
+	file, line := getLocation(0)
+	err := terr.Trace(terr.Newf("fail"), terr.WithContext(ctx))
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("fail @ %s:%d [request_id=req-1 tenant=acme]", file, line+1),
+		fmt.Sprintf("\tfail @ %s:%d", file, line+1),
+	}, "\n"))
+
+	err = terr.Trace(terr.Newf("fail"), terr.WithContext(context.Background()))
+	assertEquals(t, terr.Metadata(terr.TraceTree(err)) == nil, true)
+
+	terr.SetContextKeys(nil)
+	err = terr.Trace(terr.Newf("fail"), terr.WithContext(ctx))
+	assertEquals(t, terr.Metadata(terr.TraceTree(err)) == nil, true)
+}
@@ -0,0 +1,297 @@
+package terr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// ErrInvalidTraceTree is wrapped by the errors returned by DecodeTraceTree
+// for malformed input or input exceeding the decoding limits.
+var ErrInvalidTraceTree = errors.New("invalid error tracing tree")
+
+// DecodeLimits limits the error tracing trees accepted by DecodeTraceTree,
+// so trees received from untrusted clients cannot exhaust resources.
+type DecodeLimits struct {
+	// MaxDepth is the maximum depth of the tree, with the root at depth 1.
+	MaxDepth int
+	// MaxNodes is the maximum number of nodes in the tree, counting
+	// repeated children as many times as they were repeated.
+	MaxNodes int
+	// MaxMessageLength is the maximum length of messages, in bytes.
+	MaxMessageLength int
+}
+
+// DefaultDecodeLimits are the limits used by DecodeTraceTree for zero fields
+// of the given limits.
+var DefaultDecodeLimits = DecodeLimits{
+	MaxDepth:         64,
+	MaxNodes:         10000,
+	MaxMessageLength: 64 << 10,
+}
+
+// treeDecoder decodes error tracing trees from their JSON representation.
+type treeDecoder struct {
+	dec    *json.Decoder
+	names  FieldNames
+	limits DecodeLimits
+	nodes  int
+}
+
+// DecodeTraceTree decodes an error tracing tree from its JSON
+// representation, as encoded by json.Marshal for traced errors with the
+// field names set with SetFieldNames, so trees emitted by other services can
+// be inspected or re-attached with WithChildren. Unknown keys are ignored,
+// and nodes without a file, such as untraced leaves, have no location.
+// Input that is malformed or exceeds limits is rejected with a traced error
+// of kind Invalid wrapping ErrInvalidTraceTree, which describes the problem
+// and the JSON pointer of the offending node (e.g., "/children/0").
+func DecodeTraceTree(data []byte, limits DecodeLimits) (ErrorTracer, error) {
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = DefaultDecodeLimits.MaxDepth
+	}
+	if limits.MaxNodes <= 0 {
+		limits.MaxNodes = DefaultDecodeLimits.MaxNodes
+	}
+	if limits.MaxMessageLength <= 0 {
+		limits.MaxMessageLength = DefaultDecodeLimits.MaxMessageLength
+	}
+	d := &treeDecoder{
+		dec:    json.NewDecoder(bytes.NewReader(data)),
+		names:  getFieldNames(),
+		limits: limits,
+	}
+	et, _, err := d.node("", 1)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.dec.Token(); err != io.EOF {
+		return nil, d.invalid("", "unexpected data after the root node")
+	}
+	return et, nil
+}
+
+// UnmarshalTraceTree decodes an error tracing tree from its JSON
+// representation, as DecodeTraceTree does with DefaultDecodeLimits, so a
+// tree emitted by one service can be re-attached in another with
+// WithChildren:
+//
+//	if et, err := terr.UnmarshalTraceTree(body); err == nil {
+//		return terr.Trace(errUpstream, terr.WithChildren(et))
+//	}
+func UnmarshalTraceTree(data []byte) (ErrorTracer, error) {
+	return DecodeTraceTree(data, DecodeLimits{})
+}
+
+// invalid returns the error for a problem found in the node at path.
+func (d *treeDecoder) invalid(path, problem string) error {
+	if path == "" {
+		path = "/"
+	}
+	return NewfWith([]TraceOption{WithKind(Invalid)}, "%w: %s at %s", ErrInvalidTraceTree, problem, path)
+}
+
+// delim reads the next token, which must be the given delimiter.
+func (d *treeDecoder) delim(path string, delim json.Delim) error {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return d.invalid(path, err.Error())
+	}
+	if tok != delim {
+		return d.invalid(path, "expected "+strconv.Quote(delim.String()))
+	}
+	return nil
+}
+
+// value decodes the next value into v, which must be a pointer.
+func (d *treeDecoder) value(path, key string, v any) error {
+	if err := d.dec.Decode(v); err != nil {
+		return d.invalid(path, "invalid "+strconv.Quote(key)+": "+err.Error())
+	}
+	return nil
+}
+
+// node decodes the node at path, which is at the given depth, returning it
+// along with the number of times it was repeated.
+func (d *treeDecoder) node(path string, depth int) (*tracedError, int, error) {
+	if depth > d.limits.MaxDepth {
+		return nil, 0, d.invalid(path, "maximum depth of "+strconv.Itoa(d.limits.MaxDepth)+" exceeded")
+	}
+	if err := d.countNodes(path, 1); err != nil {
+		return nil, 0, err
+	}
+	if err := d.delim(path, '{'); err != nil {
+		return nil, 0, err
+	}
+
+	e := &tracedError{}
+	loc := &location{}
+	var message *string
+	count := 1
+	var children []*tracedError
+	for d.dec.More() {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return nil, 0, d.invalid(path, err.Error())
+		}
+		key, _ := tok.(string)
+		switch key {
+		case d.names.Message:
+			if err := d.value(path, key, &message); err != nil {
+				return nil, 0, err
+			}
+			if message != nil && len(*message) > d.limits.MaxMessageLength {
+				return nil, 0, d.invalid(path, "maximum message length of "+strconv.Itoa(d.limits.MaxMessageLength)+" exceeded")
+			}
+		case d.names.File:
+			if d.names.Location != "" {
+				break
+			}
+			err = d.value(path, key, &loc.file)
+		case d.names.Line:
+			if d.names.Location != "" {
+				break
+			}
+			err = d.value(path, key, &loc.line)
+		case d.names.Location:
+			if d.names.Location == "" {
+				break
+			}
+			err = d.location(path, key, loc)
+		case d.names.ID:
+			err = d.value(path, key, &e.id)
+		case d.names.Code:
+			err = d.value(path, key, &e.code)
+		case d.names.Kind:
+			var kind string
+			if err = d.value(path, key, &kind); err == nil {
+				if e.kind = parseKind(kind); e.kind == Unknown && kind != Unknown.String() {
+					err = d.invalid(path, "unknown kind "+strconv.Quote(kind))
+				}
+			}
+		case d.names.Domain:
+			err = d.value(path, key, &e.domain)
+		case d.names.Op:
+			err = d.value(path, key, &e.op)
+		case d.names.Tags:
+			err = d.value(path, key, &e.tags)
+		case d.names.Hints:
+			err = d.value(path, key, &e.hints)
+		case d.names.URL:
+			err = d.value(path, key, &e.url)
+		case d.names.Time:
+			err = d.value(path, key, &e.time)
+		case d.names.Metadata:
+			err = d.metadata(path, e)
+		case d.names.Omitted:
+			err = d.value(path, key, &e.omitted)
+			if err == nil && e.omitted < 0 {
+				err = d.invalid(path, "negative "+strconv.Quote(key))
+			}
+		case d.names.Count:
+			if err = d.value(path, key, &count); err == nil {
+				if count < 1 {
+					err = d.invalid(path, "non-positive "+strconv.Quote(key))
+				} else {
+					err = d.countNodes(path, count-1)
+				}
+			}
+		case d.names.Children:
+			children, err = d.children(path, depth)
+		default:
+			var ignored json.RawMessage
+			err = d.value(path, key, &ignored)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := d.delim(path, '}'); err != nil {
+		return nil, 0, err
+	}
+
+	if message == nil {
+		return nil, 0, d.invalid(path, "missing "+strconv.Quote(d.names.Message))
+	}
+	if loc.line < 0 {
+		return nil, 0, d.invalid(path, "negative "+strconv.Quote(d.names.Line))
+	}
+	e.error = errors.New(*message)
+	e.loc.Store(loc)
+	for _, child := range children {
+		e.addChild(child)
+	}
+	return e, count, nil
+}
+
+// location decodes the location of the node at path, nested under key,
+// into loc.
+func (d *treeDecoder) location(path, key string, loc *location) error {
+	var nested map[string]json.RawMessage
+	if err := d.value(path, key, &nested); err != nil {
+		return err
+	}
+	if file, ok := nested[d.names.File]; ok && json.Unmarshal(file, &loc.file) != nil {
+		return d.invalid(path, "invalid "+strconv.Quote(d.names.File))
+	}
+	if line, ok := nested[d.names.Line]; ok && json.Unmarshal(line, &loc.line) != nil {
+		return d.invalid(path, "invalid "+strconv.Quote(d.names.Line))
+	}
+	return nil
+}
+
+// metadata decodes the metadata of the node at path into e, keeping the
+// order of its keys.
+func (d *treeDecoder) metadata(path string, e *tracedError) error {
+	path += "/" + d.names.Metadata
+	if err := d.delim(path, '{'); err != nil {
+		return err
+	}
+	for d.dec.More() {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return d.invalid(path, err.Error())
+		}
+		key, _ := tok.(string)
+		var value any
+		if err := d.value(path, key, &value); err != nil {
+			return err
+		}
+		e.setMetadata(key, value)
+	}
+	return d.delim(path, '}')
+}
+
+// children decodes the children of the node at path, which is at the given
+// depth, adding repeated children as many times as they were repeated.
+func (d *treeDecoder) children(path string, depth int) ([]*tracedError, error) {
+	path += "/" + d.names.Children
+	if err := d.delim(path, '['); err != nil {
+		return nil, err
+	}
+	var children []*tracedError
+	for i := 0; d.dec.More(); i++ {
+		child, count, err := d.node(path+"/"+strconv.Itoa(i), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < count; j++ {
+			children = append(children, child)
+		}
+	}
+	if err := d.delim(path, ']'); err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+// countNodes adds n nodes to the number of decoded nodes, failing if the
+// limit is exceeded.
+func (d *treeDecoder) countNodes(path string, n int) error {
+	if d.nodes += n; d.nodes > d.limits.MaxNodes {
+		return d.invalid(path, "maximum number of nodes of "+strconv.Itoa(d.limits.MaxNodes)+" exceeded")
+	}
+	return nil
+}
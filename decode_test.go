@@ -0,0 +1,91 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestDecodeTraceTree(t *testing.T) {
+	leaf := terr.Trace(errors.New("timeout"),
+		terr.WithCode("db_timeout"),
+		terr.WithDomain("storage"),
+		terr.WithKind(terr.Timeout),
+		terr.WithHint("retry"),
+		terr.WithMetadata("table", "users"),
+		terr.WithMetadata("attempt", 3),
+	)
+	err := terr.Newf("query: %w, %w", leaf, leaf)
+	data := terr.Export(err)
+
+	et, decodeErr := terr.DecodeTraceTree(data, terr.DecodeLimits{})
+	assertErrorIsNil(t, decodeErr)
+	assertEquals(t, string(terr.Export(et.(error))), string(data))
+	assertEquals(t, len(et.Children()), 2)
+	assertEquals(t, terr.KindOf(et.(error)), terr.Timeout)
+
+	terr.SetFieldNames(terr.GCPFieldNames)
+	defer terr.SetFieldNames(terr.FieldNames{})
+	data = terr.Export(err)
+	et, decodeErr = terr.DecodeTraceTree(data, terr.DecodeLimits{})
+	assertErrorIsNil(t, decodeErr)
+	assertEquals(t, string(terr.Export(et.(error))), string(data))
+}
+
+func TestDecodeTraceTreeInvalid(t *testing.T) {
+	node := func(children string) string {
+		return `{"message":"fail","file":"a.go","line":1,"children":[` + children + `]}`
+	}
+	tests := []struct {
+		data   string
+		limits terr.DecodeLimits
+		want   string
+	}{
+		{`[]`, terr.DecodeLimits{}, `expected "{" at /`},
+		{`{"message":"fail","file":"a.go"`, terr.DecodeLimits{}, `at /`},
+		{`{"message":"fail","file":"a.go"} {}`, terr.DecodeLimits{}, `unexpected data after the root node at /`},
+		{`{"file":"a.go"}`, terr.DecodeLimits{}, `missing "message" at /`},
+		{node(`{"message":"fail","file":"a.go","line":"1"}`), terr.DecodeLimits{}, `invalid "line": `},
+		{node(`{"message":"fail","file":"a.go","line":-1}`), terr.DecodeLimits{}, `negative "line" at /children/0`},
+		{node(`{"message":"fail","file":"a.go","kind":"bad"}`), terr.DecodeLimits{}, `unknown kind "bad" at /children/0`},
+		{node(`{"message":"fail","file":"a.go","count":0}`), terr.DecodeLimits{}, `non-positive "count" at /children/0`},
+		{node(node(node(""))), terr.DecodeLimits{MaxDepth: 2}, `maximum depth of 2 exceeded at /children/0/children/0`},
+		{node(`{"message":"fail","file":"a.go","count":1000000}`), terr.DecodeLimits{}, `maximum number of nodes of 10000 exceeded at /children/0`},
+		{node(node("") + "," + node("")), terr.DecodeLimits{MaxNodes: 2}, `maximum number of nodes of 2 exceeded at /children/1`},
+		{`{"message":"` + strings.Repeat("x", 11) + `","file":"a.go"}`, terr.DecodeLimits{MaxMessageLength: 10}, `maximum message length of 10 exceeded at /`},
+	}
+	for _, test := range tests {
+		et, err := terr.DecodeTraceTree([]byte(test.data), test.limits)
+		if et != nil || err == nil {
+			t.Fatalf("want error for %s", test.data)
+		}
+		assertEquals(t, errors.Is(err, terr.ErrInvalidTraceTree), true)
+		assertEquals(t, terr.KindOf(err), terr.Invalid)
+		if !strings.Contains(err.Error(), test.want) {
+			t.Fatalf("want error containing %q for %s, got %q", test.want, test.data, err.Error())
+		}
+	}
+}
+
+func TestUnmarshalTraceTree(t *testing.T) {
+	file, line := getLocation(0)
+	data := terr.Export(terr.Newf("upstream: %w", terr.Newf("timeout")))
+
+	et, err := terr.UnmarshalTraceTree(data)
+	assertErrorIsNil(t, err)
+	errUpstream := errors.New("upstream failed")
+	traced := terr.Trace(errUpstream, terr.WithChildren(et))
+	assertEquals(t, terr.Sprint(traced), strings.Join([]string{
+		fmt.Sprintf("upstream failed @ %s:%d", file, line+6),
+		fmt.Sprintf("\tupstream: timeout @ %s:%d", file, line+1),
+		fmt.Sprintf("\t\ttimeout @ %s:%d", file, line+1),
+	}, "\n"))
+
+	_, err = terr.UnmarshalTraceTree([]byte(`{}`))
+	assertEquals(t, errors.Is(err, terr.ErrInvalidTraceTree), true)
+}
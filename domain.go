@@ -0,0 +1,120 @@
+package terr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithDomain sets the domain of the traced error, such as "billing" or
+// "auth", standardizing error taxonomy across a large code base. The domain
+// is used as a prefix for the code of the traced error, if any, so a traced
+// error with the "card_declined" code in the "billing" domain has the
+// "billing.card_declined" code.
+func WithDomain(domain string) TraceOption {
+	return func(e *tracedError) {
+		e.domain = domain
+	}
+}
+
+// WithTags adds tags to the traced error, which can be used for filtering
+// and grouping errors.
+func WithTags(tags ...string) TraceOption {
+	return func(e *tracedError) {
+		e.tags = append(e.tags, tags...)
+	}
+}
+
+// DomainOf returns the domain of et, or an empty string if it has none.
+func DomainOf(et ErrorTracer) string {
+	if te, ok := et.(*tracedError); ok {
+		return te.domain
+	}
+	return ""
+}
+
+// Tags returns the tags of et.
+func Tags(et ErrorTracer) []string {
+	if te, ok := et.(*tracedError); ok && len(te.tags) > 0 {
+		return append([]string(nil), te.tags...)
+	}
+	return nil
+}
+
+// fullCode returns the code of e, prefixed by its domain, if any.
+func (e *tracedError) fullCode() string {
+	if e.code == "" || e.domain == "" || strings.HasPrefix(e.code, e.domain+".") {
+		return e.code
+	}
+	return e.domain + "." + e.code
+}
+
+// ErrorDomain creates traced errors belonging to a domain.
+type ErrorDomain struct {
+	name string
+	tags []string
+}
+
+// Domain returns an ErrorDomain creating traced errors in the given domain
+// and with the given default tags. It is meant to be stored in a package
+// variable and used instead of the package-level functions:
+//
+//	var billing = terr.Domain("billing", "payments")
+//
+//	func charge() error {
+//		return billing.Trace(err, terr.WithCode("card_declined"))
+//	}
+func Domain(name string, tags ...string) *ErrorDomain {
+	return &ErrorDomain{name: name, tags: tags}
+}
+
+// Name returns the name of the domain.
+func (d *ErrorDomain) Name() string {
+	return d.name
+}
+
+// options returns opts followed by the options applying the domain and its
+// default tags.
+func (d *ErrorDomain) options(opts []TraceOption) []TraceOption {
+	domainOpts := make([]TraceOption, 0, len(opts)+2)
+	domainOpts = append(domainOpts, WithDomain(d.name))
+	if len(d.tags) > 0 {
+		domainOpts = append(domainOpts, WithTags(d.tags...))
+	}
+	return append(domainOpts, opts...)
+}
+
+// Newf works exactly like the package-level Newf, but the returned traced
+// error belongs to the domain.
+func (d *ErrorDomain) Newf(format string, a ...any) error {
+	return newf(fmt.Errorf(format, a...), a, d.options(nil))
+}
+
+// NewfWith works exactly like the package-level NewfWith, but the returned
+// traced error belongs to the domain.
+func (d *ErrorDomain) NewfWith(opts []TraceOption, format string, a ...any) error {
+	return newf(fmt.Errorf(format, a...), a, d.options(opts))
+}
+
+// Trace works exactly like the package-level Trace, but the returned traced
+// error belongs to the domain.
+func (d *ErrorDomain) Trace(err error, opts ...TraceOption) error {
+	if err == nil {
+		return nil
+	}
+	if te := newTracedError(err, []any{err}, 0, d.options(opts)); te != nil {
+		return te
+	}
+	return err
+}
+
+// TraceSkip works exactly like the package-level TraceSkip, but the returned
+// traced error belongs to the domain.
+func (d *ErrorDomain) TraceSkip(err error, skip int, opts ...TraceOption) error {
+	if err == nil {
+		return nil
+	}
+	if te := newTracedError(err, []any{err}, skip, d.options(opts)); te != nil {
+		return te
+	}
+	return err
+}
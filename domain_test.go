@@ -0,0 +1,75 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+var billing = terr.Domain("billing", "payments")
+
+func TestDomain(t *testing.T) {
+	file, line := getLocation(0)
+	err := billing.Newf("card declined")
+	traced := billing.Trace(err, terr.WithCode("card_declined"), terr.WithTags("cards"))
+	newSkip := func() error {
+		return billing.TraceSkip(traced, 1)
+	}
+	skipped := newSkip()
+
+	assertEquals(t, billing.Name(), "billing")
+	assertEquals(t, terr.Code(traced), "billing.card_declined")
+	assertEquals(t, terr.DomainOf(terr.TraceTree(err)), "billing")
+	assertEquals(t, strings.Join(terr.Tags(terr.TraceTree(traced)), ","), "payments,cards")
+	assertEquals(t, strings.Join(terr.Tags(terr.TraceTree(err)), ","), "payments")
+	assertEquals(t, fmt.Sprintf("%@", skipped), strings.Join([]string{
+		fmt.Sprintf("card declined @ %s:%d", file, line+6),
+		fmt.Sprintf("\tcard declined @ %s:%d", file, line+2),
+		fmt.Sprintf("\t\tcard declined @ %s:%d", file, line+1),
+	}, "\n"))
+
+	b, jsonErr := json.Marshal(traced)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, strings.HasPrefix(string(b), fmt.Sprintf(
+		`{"message":"card declined","file":%q,"line":%d,"code":"billing.card_declined","domain":"billing","tags":["payments","cards"],"children":[`,
+		file, line+2)), true)
+
+	withErr := billing.NewfWith([]terr.TraceOption{terr.WithCode("card_expired")}, "card expired")
+	assertEquals(t, terr.Code(withErr), "billing.card_expired")
+	withFile, withLine := terr.TraceTree(withErr).Location()
+	assertEquals(t, withFile, file)
+	assertEquals(t, withLine, line+25)
+
+	assertErrorIsNil(t, billing.Trace(nil))
+	assertErrorIsNil(t, billing.TraceSkip(nil, 0))
+}
+
+func TestWithDomain(t *testing.T) {
+	err := terr.Trace(terr.Newf("fail"), terr.WithCode("code"), terr.WithDomain("auth"))
+	assertEquals(t, terr.Code(err), "auth.code")
+	err = terr.Trace(terr.Newf("fail"), terr.WithCode("auth.code"), terr.WithDomain("auth"))
+	assertEquals(t, terr.Code(err), "auth.code")
+	assertEquals(t, terr.DomainOf(terr.TraceTree(terr.Newf("fail"))), "")
+	assertEquals(t, terr.Tags(terr.TraceTree(terr.Newf("fail"))) == nil, true)
+}
+
+func TestDomainJoin(t *testing.T) {
+	first, second := errors.New("first"), errors.New("second")
+
+	err := billing.Newf("batch: %w", terr.Join(first, second))
+	assertEquals(t, err.Error(), "batch: [first second]")
+	assertEquals(t, errors.Is(err, first), true)
+	assertEquals(t, errors.Is(err, second), true)
+	assertEquals(t, len(terr.TraceTree(err).Children()), 2)
+
+	err = billing.NewfWith([]terr.TraceOption{terr.WithCode("batch")}, "batch: %w", terr.Join(first, second))
+	assertEquals(t, err.Error(), "batch: [first second]")
+	assertEquals(t, errors.Is(err, second), true)
+	assertEquals(t, terr.Code(err), "billing.batch")
+}
@@ -0,0 +1,102 @@
+package terr
+
+import (
+	"strconv"
+)
+
+// ExportECS returns the traced error err as a JSON object with Elastic
+// Common Schema (ECS) fields, customized by opts as in Export, so it can be
+// ingested by Elasticsearch without custom ingest pipelines. The object
+// includes the error.message, error.code, error.type (the kind, if any),
+// error.id and error.stack_trace fields, the log.origin.file.name,
+// log.origin.file.line and log.origin.function fields for the location of
+// the root of the error tracing tree, and its labels (metadata) and tags.
+// The host, environment and labels set with SetServiceInfo are included as
+// the host.name, service.environment and labels fields. The stack trace is
+// the error tracing tree as printed by the %@ verb, and it is omitted if file
+// paths are transformed or any messages redacted, since it contains the
+// original ones. Returns nil if err is not a traced error.
+func ExportECS(err error, opts ...ExportOption) []byte {
+	te, ok := err.(*tracedError)
+	if !ok || te == nil {
+		return nil
+	}
+	cfg := defaultExportConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	te.report()
+
+	loc := te.resolveLocation()
+	file := loc.file
+	if cfg.path != nil {
+		file = cfg.path(file)
+	}
+	message, metadata := te.Error(), te.metadata
+	redact := cfg.redact || te.redacted
+	if redact {
+		message, metadata = redactedMessage(te), nil
+	}
+
+	dst := []byte(`{"error":{"message":`)
+	dst = appendJSONString(dst, message)
+	if code := te.fullCode(); code != "" {
+		dst = append(dst, `,"code":`...)
+		dst = appendJSONString(dst, code)
+	}
+	if kind := KindOf(te); kind != Unknown {
+		dst = append(dst, `,"type":`...)
+		dst = appendJSONString(dst, kind.String())
+	}
+	if te.id != "" {
+		dst = append(dst, `,"id":`...)
+		dst = appendJSONString(dst, te.id)
+	}
+	// The stack trace is printed as is, so it cannot include redacted
+	// traced errors.
+	anyRedacted := walkTree(te, func(et ErrorTracer) bool { return !isRedacted(et) })
+	if !redact && !anyRedacted && cfg.path == nil {
+		dst = append(dst, `,"stack_trace":`...)
+		dst = appendJSONString(dst, te.tree())
+	}
+	dst = append(dst, `},"log":{"origin":{"file":{"name":`...)
+	dst = appendJSONString(dst, file)
+	dst = append(dst, `,"line":`...)
+	dst = strconv.AppendInt(dst, int64(loc.line), 10)
+	dst = append(dst, '}')
+	if loc.function != "" {
+		dst = append(dst, `,"function":`...)
+		dst = appendJSONString(dst, loc.function)
+	}
+	dst = append(dst, "}}"...)
+	if cfg.service != nil && cfg.service.Host != "" {
+		dst = append(dst, `,"host":{"name":`...)
+		dst = appendJSONString(dst, cfg.service.Host)
+		dst = append(dst, '}')
+	}
+	if cfg.service != nil && cfg.service.Environment != "" {
+		dst = append(dst, `,"service":{"environment":`...)
+		dst = appendJSONString(dst, cfg.service.Environment)
+		dst = append(dst, '}')
+	}
+	if cfg.service != nil {
+		for _, k := range cfg.service.sortedLabels() {
+			metadata = append(metadata[:len(metadata):len(metadata)], metadatum{k, cfg.service.Labels[k]})
+		}
+	}
+	if len(metadata) > 0 {
+		dst = append(dst, `,"labels":`...)
+		dst = appendMetadataJSON(dst, metadata)
+	}
+	if len(te.tags) > 0 {
+		dst = append(dst, `,"tags":[`...)
+		for i, tag := range te.tags {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, tag)
+		}
+		dst = append(dst, ']')
+	}
+	return append(dst, '}')
+}
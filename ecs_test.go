@@ -0,0 +1,55 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestExportECS(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Trace(errors.New("not found"), terr.WithCode("missing"), terr.WithKind(terr.NotFound),
+		terr.WithMetadata("user", "alice"), terr.WithTags("storage"))
+
+	var doc struct {
+		Error struct {
+			Message    string `json:"message"`
+			Code       string `json:"code"`
+			Type       string `json:"type"`
+			StackTrace string `json:"stack_trace"`
+		} `json:"error"`
+		Log struct {
+			Origin struct {
+				File struct {
+					Name string `json:"name"`
+					Line int    `json:"line"`
+				} `json:"file"`
+				Function string `json:"function"`
+			} `json:"origin"`
+		} `json:"log"`
+		Labels map[string]string `json:"labels"`
+		Tags   []string          `json:"tags"`
+	}
+	assertErrorIsNil(t, json.Unmarshal(terr.ExportECS(err), &doc))
+	assertEquals(t, doc.Error.Message, "not found")
+	assertEquals(t, doc.Error.Code, "missing")
+	assertEquals(t, doc.Error.Type, "not_found")
+	assertEquals(t, doc.Error.StackTrace, terr.Sprint(err))
+	assertEquals(t, doc.Log.Origin.File.Name, file)
+	assertEquals(t, doc.Log.Origin.File.Line, line+1)
+	assertEquals(t, doc.Log.Origin.Function, "github.com/alnvdl/terr_test.TestExportECS")
+	assertEquals(t, doc.Labels["user"], "alice")
+	assertEquals(t, len(doc.Tags), 1)
+
+	stripped := filepath.Base(filepath.Dir(file)) + "/" + filepath.Base(file)
+	assertEquals(t, string(terr.ExportECS(err, terr.RedactMessages(), terr.StripPaths())), fmt.Sprintf(
+		`{"error":{"message":"missing","code":"missing","type":"not_found"},"log":{"origin":{"file":{"name":%q,"line":%d},`+
+			`"function":"github.com/alnvdl/terr_test.TestExportECS"}},"tags":["storage"]}`, stripped, line+1))
+	assertEquals(t, terr.ExportECS(errors.New("fail")) == nil, true)
+}
@@ -125,3 +125,13 @@ func ExampleTraceTree() {
 	printNode(node.Children()[0])
 	printNode(node.Children()[1])
 }
+
+// This example shows how to print an error tracing tree without using the %@
+// verb directly.
+func ExampleSprint() {
+	err := terr.Newf("base")
+	traced := terr.Trace(err)
+	fmt.Println(terr.Sprint(traced))
+	// Non-traced errors are printed as fmt.Sprint would.
+	fmt.Println(terr.Sprint(errors.New("non-traced")))
+}
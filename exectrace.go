@@ -0,0 +1,20 @@
+package terr
+
+import (
+	"sync/atomic"
+)
+
+// executionTraceEvents is whether execution trace events are emitted when
+// traced errors are created.
+var executionTraceEvents atomic.Bool
+
+// SetExecutionTraceEvents sets whether a runtime/trace user log event is
+// emitted, in the "terr" category, with the message and location of each
+// traced error created while an execution trace is being collected. This
+// shows where errors occurred relative to goroutine scheduling when
+// inspecting execution traces collected during incidents. It is disabled by
+// default, and it has no effect in the reduced-footprint mode selected with
+// the terr_tiny build tag.
+func SetExecutionTraceEvents(enabled bool) {
+	executionTraceEvents.Store(enabled)
+}
@@ -0,0 +1,18 @@
+//go:build !terr_tiny && !tinygo
+
+package terr
+
+import (
+	"context"
+	"runtime/trace"
+	"strconv"
+)
+
+// traceEvent emits an execution trace event for e, if enabled.
+func (e *tracedError) traceEvent() {
+	if !executionTraceEvents.Load() || !trace.IsEnabled() {
+		return
+	}
+	file, line := e.Location()
+	trace.Log(context.Background(), "terr", e.Error()+" @ "+file+":"+strconv.Itoa(line))
+}
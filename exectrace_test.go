@@ -0,0 +1,28 @@
+//go:build !terr_tiny && !tinygo && !terr_noop
+
+package terr_test
+
+import (
+	"bytes"
+	"runtime/trace"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestSetExecutionTraceEvents(t *testing.T) {
+	terr.SetExecutionTraceEvents(true)
+	defer terr.SetExecutionTraceEvents(false)
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skipf("cannot collect execution traces: %v", err)
+	}
+	terr.Newf("traced failure")
+	terr.SetExecutionTraceEvents(false)
+	terr.Newf("untraced failure")
+	trace.Stop()
+
+	assertEquals(t, bytes.Contains(buf.Bytes(), []byte("traced failure @ ")), true)
+	assertEquals(t, bytes.Contains(buf.Bytes(), []byte("untraced failure")), false)
+}
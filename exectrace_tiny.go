@@ -0,0 +1,7 @@
+//go:build terr_tiny || tinygo
+
+package terr
+
+// traceEvent does nothing in the reduced-footprint mode, which does not
+// depend on runtime/trace.
+func (e *tracedError) traceEvent() {}
@@ -0,0 +1,132 @@
+package terr
+
+import (
+	"hash/fnv"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// exportConfig defines how error tracing trees are exported.
+type exportConfig struct {
+	names FieldNames
+	// path transforms file paths, if set.
+	path func(string) string
+	// redact is whether messages and metadata should be redacted.
+	redact bool
+	// service describes the service, if set, and it is only included in the
+	// root of error tracing trees.
+	service *ServiceInfo
+	// canonical is whether error IDs are omitted, as done by ExportCanonical.
+	canonical bool
+	// order is the order of children, as set with SortChildren.
+	order ChildOrder
+}
+
+// defaultExportConfig returns the configuration used when no export options
+// are given.
+func defaultExportConfig() *exportConfig {
+	return &exportConfig{names: getFieldNames(), service: getServiceInfo()}
+}
+
+// ExportOption is an option that can be passed to Export to customize how
+// error tracing trees are exported.
+type ExportOption func(*exportConfig)
+
+// Export returns the JSON representation of the error tracing tree for err,
+// as encoded by json.Marshal for traced errors, customized by opts. It is
+// meant for sending error tracing trees to third-party error reporters. Like
+// TraceTree, it also finds traced errors wrapped by non-traced errors, in
+// which case the tree of the nearest traced error is exported. Returns nil if
+// err has no traced error.
+func Export(err error, opts ...ExportOption) []byte {
+	te := findTraced(err)
+	if te == nil {
+		return nil
+	}
+	cfg := defaultExportConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	te.report()
+	return appendJSON(nil, te, cfg, 1)
+}
+
+// splitPath splits a slash-separated file path into its parent directories,
+// its innermost directory (a hint of the package it belongs to) and its base
+// name. Any of these parts may be empty.
+func splitPath(file string) (parents, dir, base string) {
+	file = strings.ReplaceAll(file, "\\", "/")
+	dirPath, base := path.Split(file)
+	dirPath = strings.TrimSuffix(dirPath, "/")
+	if i := strings.LastIndex(dirPath, "/"); i >= 0 {
+		return dirPath[:i+1], dirPath[i+1:], base
+	}
+	return "", dirPath, base
+}
+
+// NormalizePath converts backslashes in file to slashes and removes its drive
+// letter, if any, so Windows paths like "C:\src\app\main.go" or
+// "C:/src/app/main.go" become "/src/app/main.go". Other paths are left
+// unchanged. It can be set as the Location transformation of a Config, so
+// locations of traced errors created on Windows are consistent with the ones
+// created on other platforms.
+func NormalizePath(file string) string {
+	file = strings.ReplaceAll(file, "\\", "/")
+	if len(file) >= 2 && file[1] == ':' &&
+		('a' <= file[0] && file[0] <= 'z' || 'A' <= file[0] && file[0] <= 'Z') {
+		file = file[2:]
+	}
+	return file
+}
+
+// StripPaths strips all but the innermost directory from file paths when
+// exporting, so "/home/user/src/app/db/conn.go" becomes "db/conn.go". This
+// keeps a hint of the package a file belongs to without leaking the internal
+// structure of the machine where the error occurred.
+func StripPaths() ExportOption {
+	return func(cfg *exportConfig) {
+		cfg.path = func(file string) string {
+			_, dir, base := splitPath(file)
+			return path.Join(dir, base)
+		}
+	}
+}
+
+// HashPaths replaces all but the innermost directory of file paths with a
+// hash when exporting, so "/home/user/src/app/db/conn.go" becomes
+// "1b4c0f23/db/conn.go". Unlike StripPaths, files in directories with the
+// same name can still be told apart.
+func HashPaths() ExportOption {
+	return func(cfg *exportConfig) {
+		cfg.path = func(file string) string {
+			parents, dir, base := splitPath(file)
+			if parents == "" {
+				return path.Join(dir, base)
+			}
+			h := fnv.New32a()
+			h.Write([]byte(parents))
+			return path.Join(strconv.FormatUint(uint64(h.Sum32()), 16), dir, base)
+		}
+	}
+}
+
+// RedactMessages replaces the message of each traced error with its code, or
+// with the fingerprint of the tree rooted in it if it has no code, and omits
+// all metadata when exporting. The structure and locations of the error
+// tracing tree are kept, so it can be sent to third parties without risking
+// leaking personal information included in messages.
+func RedactMessages() ExportOption {
+	return func(cfg *exportConfig) {
+		cfg.redact = true
+	}
+}
+
+// redactedMessage returns the message used for et when messages are
+// redacted.
+func redactedMessage(et ErrorTracer) string {
+	if te, ok := et.(*tracedError); ok && te.code != "" {
+		return te.fullCode()
+	}
+	return treeFingerprint(et)
+}
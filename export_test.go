@@ -0,0 +1,69 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestExport(t *testing.T) {
+	err := terr.Trace(terr.Newf("fail"))
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(terr.Export(err)), string(b))
+	assertEquals(t, string(terr.Export(fmt.Errorf("wrapped: %w", err))), string(b))
+	assertEquals(t, terr.Export(errors.New("fail")) == nil, true)
+	assertEquals(t, terr.Export(nil) == nil, true)
+}
+
+func TestExportStripPaths(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Trace(terr.Newf("fail"))
+	stripped := filepath.Base(filepath.Dir(file)) + "/" + filepath.Base(file)
+
+	assertEquals(t, string(terr.Export(err, terr.StripPaths())), fmt.Sprintf(
+		`{"message":"fail","file":%q,"line":%d,"children":[{"message":"fail","file":%q,"line":%d}]}`,
+		stripped, line+1, stripped, line+1))
+}
+
+func TestExportHashPaths(t *testing.T) {
+	file, _ := getLocation(0)
+	err := terr.Newf("fail")
+	hashed := filepath.Base(filepath.Dir(file)) + "/" + filepath.Base(file)
+
+	var node struct {
+		File string `json:"file"`
+	}
+	assertErrorIsNil(t, json.Unmarshal(terr.Export(err, terr.HashPaths()), &node))
+	assertEquals(t, regexp.MustCompile(`^[0-9a-f]+/`+regexp.QuoteMeta(hashed)+`$`).MatchString(node.File), true)
+}
+
+func TestNormalizePath(t *testing.T) {
+	assertEquals(t, terr.NormalizePath(`C:\src\app\main.go`), "/src/app/main.go")
+	assertEquals(t, terr.NormalizePath("d:/src/app/main.go"), "/src/app/main.go")
+	assertEquals(t, terr.NormalizePath(`\\server\share\main.go`), "//server/share/main.go")
+	assertEquals(t, terr.NormalizePath("/src/app/main.go"), "/src/app/main.go")
+	assertEquals(t, terr.NormalizePath("main.go"), "main.go")
+
+	err := terr.Trace(errors.New("fail"), terr.WithLocation(`C:\src\main.go`, 10),
+		terr.WithConfig(terr.Config{Location: terr.NormalizePath}))
+	file, _ := terr.TraceTree(err).Location()
+	assertEquals(t, file, "/src/main.go")
+}
+
+func TestExportRedactMessages(t *testing.T) {
+	file, line := getLocation(0)
+	base := terr.Newf("user john@example.com not found")
+	err := terr.Trace(base, terr.WithCode("not_found"), terr.WithMetadata("email", "john@example.com"))
+
+	assertEquals(t, string(terr.Export(err, terr.RedactMessages())), fmt.Sprintf(
+		`{"message":"not_found","file":%q,"line":%d,"code":"not_found","children":[{"message":%q,"file":%q,"line":%d}]}`,
+		file, line+2, terr.Fingerprint(base), file, line+1))
+}
@@ -0,0 +1,199 @@
+package terr
+
+import (
+	"sync/atomic"
+)
+
+// FieldNames defines the keys used when error tracing trees are emitted as
+// structured data, like JSON objects or slog groups. Empty names are replaced
+// by the corresponding default names.
+type FieldNames struct {
+	// ID is the key for the ID of traced errors. Defaults to "id".
+	ID string
+	// Message is the key for the error message. Defaults to "message".
+	Message string
+	// File is the key for the file in the error location. Defaults to "file".
+	File string
+	// Line is the key for the line in the error location. Defaults to "line".
+	Line string
+	// Location is the key for an object nesting the file and line of the
+	// error location. By default, they are not nested.
+	Location string
+	// Code is the key for the code of traced errors, including the domain
+	// prefix. Defaults to "code".
+	Code string
+	// Kind is the key for the kind of traced errors. Defaults to "kind".
+	Kind string
+	// Domain is the key for the domain of traced errors. Defaults to
+	// "domain".
+	Domain string
+	// Tags is the key for the tags of traced errors. Defaults to "tags".
+	Tags string
+	// Op is the key for the operation of traced errors. Defaults to "op".
+	Op string
+	// Hints is the key for the remediation hints of traced errors. Defaults
+	// to "hints".
+	Hints string
+	// URL is the key for the documentation or runbook URL of traced errors.
+	// Defaults to "url".
+	URL string
+	// Children is the key for the children traced errors. Defaults to
+	// "children".
+	Children string
+	// Metadata is the key for the metadata attached to traced errors.
+	// Defaults to "metadata".
+	Metadata string
+	// Omitted is the key for the number of children omitted due to the limit
+	// set with SetMaxChildren. Defaults to "omitted".
+	Omitted string
+	// Count is the key for the number of times structurally identical
+	// children were repeated. Defaults to "count".
+	Count string
+	// Time is the key for the time when imported traced errors occurred,
+	// as set with WithTime. Defaults to "time".
+	Time string
+	// Imported is the key for the flag marking traced errors imported with
+	// WithTime. Defaults to "imported".
+	Imported string
+	// Stack is the key for the call stack captured for traced errors with
+	// Config.Stack. Defaults to "stack".
+	Stack string
+	// SharedStack is the key for the frames shared by the stacks of all
+	// children with stacks, which are omitted from their own stacks.
+	// Defaults to "shared_stack".
+	SharedStack string
+	// Host is the key for the host set with SetServiceInfo. Defaults to
+	// "host".
+	Host string
+	// Environment is the key for the environment set with SetServiceInfo.
+	// Defaults to "environment".
+	Environment string
+	// Labels is the key for the labels set with SetServiceInfo. Defaults to
+	// "labels".
+	Labels string
+}
+
+var defaultFieldNames = FieldNames{
+	ID:       "id",
+	Message:  "message",
+	File:     "file",
+	Line:     "line",
+	Code:     "code",
+	Kind:     "kind",
+	Domain:   "domain",
+	Tags:     "tags",
+	Op:       "op",
+	Hints:    "hints",
+	URL:      "url",
+	Children: "children",
+	Metadata: "metadata",
+	Omitted:  "omitted",
+	Count:    "count",
+
+	Time:        "time",
+	Imported:    "imported",
+	Stack:       "stack",
+	SharedStack: "shared_stack",
+
+	Host:        "host",
+	Environment: "environment",
+	Labels:      "labels",
+}
+
+var fieldNames atomic.Pointer[FieldNames]
+
+// fields returns pointers to all fields in names.
+func (names *FieldNames) fields() []*string {
+	return []*string{
+		&names.ID,
+		&names.Message,
+		&names.File,
+		&names.Line,
+		&names.Code,
+		&names.Kind,
+		&names.Domain,
+		&names.Tags,
+		&names.Op,
+		&names.Hints,
+		&names.URL,
+		&names.Children,
+		&names.Metadata,
+		&names.Omitted,
+		&names.Count,
+		&names.Time,
+		&names.Imported,
+		&names.Stack,
+		&names.SharedStack,
+		&names.Host,
+		&names.Environment,
+		&names.Labels,
+	}
+}
+
+// Predefined field names matching common logging schemas. They can be passed
+// to SetFieldNames as they are, or copied and adjusted first.
+var (
+	// ECSFieldNames follows the Elastic Common Schema.
+	ECSFieldNames = FieldNames{
+		ID:       "error.id",
+		Message:  "error.message",
+		File:     "log.origin.file.name",
+		Line:     "log.origin.file.line",
+		Code:     "error.code",
+		Kind:     "error.type",
+		Tags:     "tags",
+		Children: "error.causes",
+		Metadata: "labels",
+
+		Host:        "host.name",
+		Environment: "service.environment",
+		Labels:      "service.labels",
+	}
+	// OTelFieldNames follows the OpenTelemetry semantic conventions for
+	// exceptions and source code attributes.
+	OTelFieldNames = FieldNames{
+		Message:  "exception.message",
+		File:     "code.filepath",
+		Line:     "code.lineno",
+		Code:     "error.type",
+		Kind:     "exception.type",
+		Children: "exception.causes",
+		Metadata: "attributes",
+
+		Host:        "host.name",
+		Environment: "deployment.environment",
+		Labels:      "resource.attributes",
+	}
+	// GCPFieldNames follows the structured logging format of Google Cloud
+	// Logging, nesting locations in its special source location field.
+	GCPFieldNames = FieldNames{
+		File:     "file",
+		Line:     "line",
+		Location: "logging.googleapis.com/sourceLocation",
+		Metadata: "labels",
+		Labels:   "logging.googleapis.com/labels",
+	}
+)
+
+// SetFieldNames configures the keys used when emitting error tracing trees as
+// structured data for all traced errors. This function is safe for concurrent
+// use, but it is meant to be called once during program initialization, so
+// the output matches the logging schema used by the application.
+func SetFieldNames(names FieldNames) {
+	defaults := defaultFieldNames
+	defaultFields := defaults.fields()
+	for i, field := range names.fields() {
+		if *field == "" {
+			*field = *defaultFields[i]
+		}
+	}
+	fieldNames.Store(&names)
+}
+
+// getFieldNames returns the currently configured field names.
+func getFieldNames() FieldNames {
+	if names := fieldNames.Load(); names != nil {
+		return *names
+	}
+	return defaultFieldNames
+}
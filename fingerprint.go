@@ -0,0 +1,80 @@
+package terr
+
+import (
+	"hash"
+	"hash/fnv"
+	"strconv"
+)
+
+// Fingerprint returns a hexadecimal hash identifying the structure of the
+// error tracing tree for err. The fingerprint is derived from the locations
+// and codes of all traced errors in the tree, but not from their messages, so
+// errors created by the same code paths share a fingerprint even if their
+// messages contain variable data. Returns an empty string if err has no
+// traced error.
+func Fingerprint(err error) string {
+	et := TraceTree(err)
+	if et == nil {
+		return ""
+	}
+	return treeFingerprint(et)
+}
+
+// treeFingerprint returns the fingerprint of the error tracing tree rooted in
+// et.
+func treeFingerprint(et ErrorTracer) string {
+	if te, ok := et.(*tracedError); ok {
+		return te.getFingerprint()
+	}
+	h := fnv.New64a()
+	hashTree(h, et, false)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// getFingerprint returns the fingerprint of the error tracing tree rooted in
+// e, computing it only once.
+func (e *tracedError) getFingerprint() string {
+	if fp := e.fingerprint.Load(); fp != nil {
+		return *fp
+	}
+	h := fnv.New64a()
+	hashTree(h, e, false)
+	fp := strconv.FormatUint(h.Sum64(), 16)
+	e.fingerprint.Store(&fp)
+	return fp
+}
+
+// hashTree writes the structure of the error tracing tree rooted in et to h.
+// If normalized is true, the messages of its traced errors, as normalized by
+// NormalizeMessage, are written too. Nodes are kept in an explicit stack, so
+// arbitrarily deep trees cannot overflow the goroutine stack.
+func hashTree(h hash.Hash64, et ErrorTracer, normalized bool) {
+	// A nil node closes the list of children of its parent.
+	stack := []ErrorTracer{et}
+	for len(stack) > 0 {
+		et := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if et == nil {
+			h.Write([]byte{')'})
+			continue
+		}
+		file, line := et.Location()
+		h.Write([]byte(file))
+		h.Write([]byte{':'})
+		h.Write(strconv.AppendInt(nil, int64(line), 10))
+		if te, ok := et.(*tracedError); ok {
+			h.Write([]byte{'#'})
+			h.Write([]byte(te.fullCode()))
+		}
+		if normalized {
+			h.Write([]byte{'|'})
+			h.Write([]byte(NormalizeMessage(et.Error())))
+		}
+		h.Write([]byte{'('})
+		stack = append(stack, nil)
+		children := childrenOf(et)
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, children[i])
+		}
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestFingerprint(t *testing.T) {
+	newErr := func(msg string) error {
+		return terr.Trace(terr.Newf("failed: %s", msg))
+	}
+	err1 := newErr("a")
+	err2 := newErr("b")
+	err3 := terr.Trace(terr.Newf("failed: %s", "a"))
+
+	assertEquals(t, terr.Fingerprint(err1) != "", true)
+	assertEquals(t, terr.Fingerprint(err1), terr.Fingerprint(err2))
+	assertEquals(t, terr.Fingerprint(err1) != terr.Fingerprint(err3), true)
+	assertEquals(t, terr.Fingerprint(errors.New("fail")), "")
+}
+
+func TestFingerprintCode(t *testing.T) {
+	newErr := func(code string) error {
+		return terr.Trace(errors.New("fail"), terr.WithCode(code))
+	}
+	assertEquals(t, terr.Fingerprint(newErr("a")), terr.Fingerprint(newErr("a")))
+	assertEquals(t, terr.Fingerprint(newErr("a")) != terr.Fingerprint(newErr("b")), true)
+}
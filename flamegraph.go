@@ -0,0 +1,123 @@
+package terr
+
+import "strconv"
+
+// flameNode is a node of a flame graph, aggregating the error tracing tree
+// nodes found at the same chain of locations.
+type flameNode struct {
+	name     string
+	value    int
+	parent   *flameNode
+	children []*flameNode
+	index    map[string]*flameNode
+}
+
+// child returns the child of n with the given name, adding it if needed.
+func (n *flameNode) child(name string) *flameNode {
+	if c, ok := n.index[name]; ok {
+		return c
+	}
+	if n.index == nil {
+		n.index = make(map[string]*flameNode)
+	}
+	c := &flameNode{name: name, parent: n}
+	n.index[name] = c
+	n.children = append(n.children, c)
+	return c
+}
+
+// ExportFlamegraph aggregates the error tracing trees for errs into a flame
+// graph, returning its JSON representation in the format used by
+// d3-flame-graph and compatible viewers: nested objects with "name", "value"
+// and "children" keys. Each node is named after a location ("file:line"),
+// and its children are the locations found below it in the trees, from the
+// outermost to the innermost traced errors, so the graph shows where error
+// volume concentrates. The value of each node is the number of leaves (i.e.,
+// root causes) below it, counting repeated children as many times as they
+// were repeated. The root node is named "all", and errors that are not traced
+// are ignored, except for untraced leaves, which are counted in their
+// parents. Only the path transformations in opts are applied.
+func ExportFlamegraph(errs []error, opts ...ExportOption) []byte {
+	cfg := defaultExportConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	// flameItem is a node of an error tracing tree to be added under parent.
+	type flameItem struct {
+		et     ErrorTracer
+		parent *flameNode
+	}
+	root := &flameNode{name: "all"}
+	var stack []flameItem
+	for _, err := range errs {
+		if et := TraceTree(err); et != nil {
+			stack = append(stack, flameItem{et, root})
+		}
+		for len(stack) > 0 {
+			item := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			// Untraced leaves have no location, so they are counted in
+			// their parents.
+			node := item.parent
+			if file, line := item.et.Location(); file != "" {
+				if cfg.path != nil {
+					file = cfg.path(file)
+				}
+				node = node.child(file + ":" + strconv.Itoa(line))
+			}
+			children := childrenOf(item.et)
+			if len(children) == 0 {
+				for n := node; n != nil; n = n.parent {
+					n.value++
+				}
+				continue
+			}
+			for i := len(children) - 1; i >= 0; i-- {
+				stack = append(stack, flameItem{children[i], node})
+			}
+		}
+	}
+	return appendFlamegraph(nil, root)
+}
+
+// appendFlamegraph appends the JSON representation of the flame graph rooted
+// in n to dst, returning the extended buffer. Nodes are kept in an explicit
+// stack, so arbitrarily deep graphs cannot overflow the goroutine stack.
+func appendFlamegraph(dst []byte, n *flameNode) []byte {
+	// flameFrame is a node whose children are being appended.
+	type flameFrame struct {
+		n    *flameNode
+		next int
+	}
+	var frames []flameFrame
+	for {
+		dst = append(dst, `{"name":`...)
+		dst = appendJSONString(dst, n.name)
+		dst = append(dst, `,"value":`...)
+		dst = strconv.AppendInt(dst, int64(n.value), 10)
+		dst = append(dst, `,"children":[`...)
+		frames = append(frames, flameFrame{n: n})
+
+		// Find the next child to append, closing the nodes that have none
+		// left.
+		for len(frames) > 0 {
+			f := &frames[len(frames)-1]
+			if f.next < len(f.n.children) {
+				break
+			}
+			dst = append(dst, "]}"...)
+			frames = frames[:len(frames)-1]
+		}
+		if len(frames) == 0 {
+			return dst
+		}
+
+		f := &frames[len(frames)-1]
+		if f.next > 0 {
+			dst = append(dst, ',')
+		}
+		n = f.n.children[f.next]
+		f.next++
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestExportFlamegraph(t *testing.T) {
+	query := func() error {
+		return terr.Newf("query failed")
+	}
+	file, line := getLocation(0)
+	errs := []error{
+		terr.Newf("handler: %w", query()),
+		terr.Newf("handler: %w", errors.Join(query(), query())),
+		terr.Newf("handler: %w", terr.Newf("invalid input")),
+		errors.New("not traced"),
+	}
+
+	b := terr.ExportFlamegraph(errs)
+	assertEquals(t, json.Valid(b), true)
+	assertEquals(t, string(b), fmt.Sprintf(`{"name":"all","value":4,"children":[`+
+		`{"name":"%[1]s:%[2]d","value":1,"children":[{"name":"%[1]s:%[3]d","value":1,"children":[]}]},`+
+		`{"name":"%[1]s:%[4]d","value":2,"children":[{"name":"%[1]s:%[3]d","value":2,"children":[]}]},`+
+		`{"name":"%[1]s:%[5]d","value":1,"children":[{"name":"%[1]s:%[5]d","value":1,"children":[]}]}]}`,
+		file, line+2, line-2, line+3, line+4))
+
+	b = terr.ExportFlamegraph(nil)
+	assertEquals(t, string(b), `{"name":"all","value":0,"children":[]}`)
+}
@@ -0,0 +1,7 @@
+//go:build terr_tiny || tinygo
+
+package terr
+
+// tiny is whether the reduced-footprint mode is selected with the terr_tiny
+// build tag or by building with TinyGo.
+const tiny = true
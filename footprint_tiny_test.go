@@ -0,0 +1,20 @@
+//go:build terr_tiny
+
+package terr_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestTiny(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.NewfWith([]terr.TraceOption{terr.WithConfig(terr.Config{Stack: true})}, "fail")
+
+	assertEquals(t, terr.Sprint(err), fmt.Sprintf("fail @ %s:%d", file, line+1))
+	assertEquals(t, bytes.Contains(terr.Export(err), []byte(`"stack"`)), false)
+	assertEquals(t, len(terr.Stack(terr.TraceTree(terr.NewfStack(8, "fail")))), 0)
+}
@@ -0,0 +1,69 @@
+package terr
+
+import (
+	"errors"
+	"runtime"
+)
+
+// Frames returns the locations of the chain of traced errors from et down to
+// its root cause, which is the first leaf found by following the first
+// children in its error tracing tree, as runtime frames ordered from the
+// innermost (the root cause) to the outermost (et), as in stack traces. This
+// way, error tracing trees can be handed to symbolizers, profilers and
+// crash-reporting SDKs working with runtime.Frame. The PC of frames is only
+// set for traced errors created by this package, and their function is only
+// known for those implementing FuncTracer.
+// Returns nil if et is nil.
+func Frames(et ErrorTracer) []runtime.Frame {
+	var frames []runtime.Frame
+	for et != nil {
+		frame := runtime.Frame{}
+		frame.File, frame.Line = et.Location()
+		frame.Function = funcOf(et)
+		if te, ok := et.(*tracedError); ok {
+			frame.PC = te.pc
+		}
+		frames = append(frames, frame)
+		children := childrenOf(et)
+		if len(children) == 0 {
+			break
+		}
+		et = children[0]
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+// FromFrames converts runtime frames, as returned by runtime.CallersFrames,
+// into a traced error, so stacks captured by other tools can be reported
+// like other traced errors. Each frame becomes a traced error located at it,
+// with the traced errors for inner frames as children of the ones for outer
+// frames, so Frames returns the same frames for it. The root, which is the
+// traced error for the outermost frame, has message as its message, and the
+// others have the functions of their frames as their messages. Returns nil
+// if there are no frames.
+func FromFrames(frames *runtime.Frames, message string) error {
+	var inner *tracedError
+	for {
+		frame, more := frames.Next()
+		if frame.File == "" && frame.Function == "" {
+			break
+		}
+		te := &tracedError{error: errors.New(frame.Function), pc: frame.PC}
+		te.loc.Store(&location{frame.File, frame.Line, frame.Function})
+		if inner != nil {
+			te.addChild(inner)
+		}
+		inner = te
+		if !more {
+			break
+		}
+	}
+	if inner == nil {
+		return nil
+	}
+	inner.error = errors.New(message)
+	return inner
+}
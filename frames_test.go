@@ -0,0 +1,61 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestFrames(t *testing.T) {
+	file, line := getLocation(0)
+	leaf := terr.Newf("leaf")
+	err := terr.Newf("root: %w, %w", leaf, terr.Newf("other"))
+
+	frames := terr.Frames(terr.TraceTree(err))
+	assertEquals(t, len(frames), 2)
+	assertEquals(t, frames[0].File, file)
+	assertEquals(t, frames[0].Line, line+1)
+	assertEquals(t, frames[0].Function, "github.com/alnvdl/terr_test.TestFrames")
+	assertEquals(t, frames[0].PC != 0, true)
+	assertEquals(t, frames[1].Line, line+2)
+	assertEquals(t, len(terr.Frames(nil)), 0)
+
+	built := terr.NewBuilder("fail").Location("a.go", 1).Build()
+	frames = terr.Frames(terr.TraceTree(built))
+	assertEquals(t, frames[0], runtime.Frame{File: "a.go", Line: 1})
+}
+
+func TestFromFrames(t *testing.T) {
+	pcs := make([]uintptr, 8)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	_, line := getLocation(0)
+	var want []runtime.Frame
+	it := runtime.CallersFrames(pcs)
+	for {
+		frame, more := it.Next()
+		want = append(want, frame)
+		if !more {
+			break
+		}
+	}
+
+	err := terr.FromFrames(runtime.CallersFrames(pcs), "crash")
+	assertEquals(t, err.Error(), "crash")
+	file, gotLine := terr.TraceTree(err).Location()
+	assertEquals(t, file, want[len(want)-1].File)
+	assertEquals(t, gotLine, want[len(want)-1].Line)
+
+	got := terr.Frames(terr.TraceTree(err))
+	assertEquals(t, len(got), len(want))
+	assertEquals(t, got[0].Function, "github.com/alnvdl/terr_test.TestFromFrames")
+	assertEquals(t, got[0].Line, line-1)
+	for i := range want {
+		assertEquals(t, got[i].File, want[i].File)
+		assertEquals(t, got[i].Line, want[i].Line)
+		assertEquals(t, got[i].Function, want[i].Function)
+	}
+	assertEquals(t, terr.FromFrames(runtime.CallersFrames(nil), "crash"), nil)
+}
@@ -0,0 +1,49 @@
+package terr
+
+import (
+	"strconv"
+)
+
+// ExportGCP returns the traced error err as a Google Cloud Logging structured
+// log entry, customized by opts as in Export, to be written as a single line
+// to standard output or standard error. The entry has the ERROR severity, the
+// message of err, the location of the root of the error tracing tree as its
+// logging.googleapis.com/sourceLocation, so Cloud Logging links it to its
+// source line, and the error tracing tree, as encoded by Export, under the
+// "error" key of its JSON payload. Returns nil if err is not a traced error.
+func ExportGCP(err error, opts ...ExportOption) []byte {
+	te, ok := err.(*tracedError)
+	if !ok || te == nil {
+		return nil
+	}
+	cfg := defaultExportConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	te.report()
+
+	loc := te.resolveLocation()
+	file := loc.file
+	if cfg.path != nil {
+		file = cfg.path(file)
+	}
+	message := te.Error()
+	if cfg.redact || te.redacted {
+		message = redactedMessage(te)
+	}
+	dst := []byte(`{"severity":"ERROR","message":`)
+	dst = appendJSONString(dst, message)
+	dst = append(dst, `,"logging.googleapis.com/sourceLocation":{"file":`...)
+	dst = appendJSONString(dst, file)
+	// Lines are 64-bit integers, which are encoded as strings.
+	dst = append(dst, `,"line":"`...)
+	dst = strconv.AppendInt(dst, int64(loc.line), 10)
+	dst = append(dst, '"')
+	if loc.function != "" {
+		dst = append(dst, `,"function":`...)
+		dst = appendJSONString(dst, loc.function)
+	}
+	dst = append(dst, `},"error":`...)
+	dst = appendJSON(dst, te, cfg, 1)
+	return append(dst, '}')
+}
@@ -0,0 +1,22 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestExportGCP(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Trace(errors.New("fail"))
+
+	assertEquals(t, string(terr.ExportGCP(err)), fmt.Sprintf(`{"severity":"ERROR","message":"fail",`+
+		`"logging.googleapis.com/sourceLocation":{"file":%q,"line":"%d","function":"github.com/alnvdl/terr_test.TestExportGCP"},`+
+		`"error":{"message":"fail","file":%q,"line":%d}}`,
+		file, line+1, file, line+1))
+	assertEquals(t, terr.ExportGCP(errors.New("fail")) == nil, true)
+}
@@ -0,0 +1,53 @@
+package terr_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func getLocation(depth int) (string, int) {
+	_, file, line, _ := runtime.Caller(depth + 1)
+	return file, line
+}
+
+func assertEquals[T comparable](t *testing.T, got, want T) {
+	if got != want {
+		t.Fatalf("want %#v got %#v", want, got)
+	}
+}
+
+func assertErrorIsNil(t *testing.T, got error) {
+	if got != nil {
+		t.Fatalf("want nil error, got %#v", got)
+	}
+}
+
+func assertTraceTreeEquals(t *testing.T, got terr.ErrorTracer, want terr.ErrorTracer) {
+	if got == nil && want == nil {
+		return
+	}
+	if got != nil && want == nil {
+		t.Fatalf("want traced error children is nil but got traced error children isn't nil")
+	}
+	if got == nil && want != nil {
+		t.Fatalf("want trace is not nil but got trace is nil")
+	}
+
+	gotFile, gotLine := got.Location()
+	wantFile, wantLine := want.Location()
+	assertEquals(t, gotFile, wantFile)
+	assertEquals(t, gotLine, wantLine)
+	assertEquals(t, got.Error(), want.Error())
+	if len(got.Children()) != len(want.Children()) {
+		t.Fatalf("want trace tree with %d children, got trace tree with %d children: want %#v got %#v ",
+			len(want.Children()),
+			len(got.Children()),
+			want.Children(),
+			got.Children())
+	}
+	for i := range got.Children() {
+		assertTraceTreeEquals(t, got.Children()[i], want.Children()[i])
+	}
+}
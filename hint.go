@@ -0,0 +1,27 @@
+package terr
+
+// WithHint adds a remediation hint to the traced error, such as "check that
+// the database migrations ran". Hints are meant to give actionable advice to
+// whoever reads the error tracing tree, and they are included when printing
+// or emitting it.
+func WithHint(hint string) TraceOption {
+	return func(e *tracedError) {
+		e.hints = append(e.hints, hint)
+	}
+}
+
+// Hints returns the remediation hints of et.
+func Hints(et ErrorTracer) []string {
+	if te, ok := et.(*tracedError); ok && len(te.hints) > 0 {
+		return append([]string(nil), te.hints...)
+	}
+	return nil
+}
+
+// hintsOf returns the hints of et without copying them.
+func hintsOf(et ErrorTracer) []string {
+	if te, ok := et.(*tracedError); ok {
+		return te.hints
+	}
+	return nil
+}
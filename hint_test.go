@@ -0,0 +1,35 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestWithHint(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Trace(errors.New("no such table"), terr.WithHint("check that the migrations ran"))
+	err = terr.Trace(err, terr.WithHint("retry later"), terr.WithHint("contact support"))
+
+	assertEquals(t, strings.Join(terr.Hints(terr.TraceTree(err)), ","), "retry later,contact support")
+	assertEquals(t, terr.Hints(terr.TraceTree(terr.Newf("fail"))) == nil, true)
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("no such table @ %s:%d", file, line+2),
+		"\t(hint: retry later)",
+		"\t(hint: contact support)",
+		fmt.Sprintf("\tno such table @ %s:%d", file, line+1),
+		"\t\t(hint: check that the migrations ran)",
+	}, "\n"))
+
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(b), fmt.Sprintf(`{"message":"no such table","file":%q,"line":%d,"hints":["retry later","contact support"],"children":[`+
+		`{"message":"no such table","file":%q,"line":%d,"hints":["check that the migrations ran"]}]}`,
+		file, line+2, file, line+1))
+}
@@ -0,0 +1,177 @@
+// Package httphandler adapts HTTP handlers returning errors into
+// http.Handlers, standardizing how returned errors are traced and turned into
+// responses:
+//
+//	http.Handle("/users", httphandler.Adapt(func(w http.ResponseWriter, r *http.Request) error {
+//		user, err := findUser(r.Context(), r.URL.Query().Get("id"))
+//		if err != nil {
+//			return err
+//		}
+//		return json.NewEncoder(w).Encode(user)
+//	}))
+package httphandler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/alnvdl/terr"
+)
+
+// Status is the key under which an HTTP status code can be attached to
+// traced errors, overriding the status code derived from their kind:
+//
+//	return terr.Trace(err, terr.WithValue(httphandler.Status, http.StatusTeapot))
+var Status = terr.NewKey[int]("http_status")
+
+// StatusClientClosedRequest is the non-standard status code used for
+// canceled requests, following the convention of nginx.
+const StatusClientClosedRequest = 499
+
+// kindStatus maps kinds of errors to HTTP status codes.
+var kindStatus = map[terr.Kind]int{
+	terr.NotFound:         http.StatusNotFound,
+	terr.AlreadyExists:    http.StatusConflict,
+	terr.PermissionDenied: http.StatusForbidden,
+	terr.Unauthenticated:  http.StatusUnauthorized,
+	terr.Invalid:          http.StatusBadRequest,
+	terr.Conflict:         http.StatusConflict,
+	terr.Unavailable:      http.StatusServiceUnavailable,
+	terr.Timeout:          http.StatusGatewayTimeout,
+	terr.Canceled:         StatusClientClosedRequest,
+}
+
+// StatusOf returns the HTTP status code for err: the status code attached to
+// it under Status, if any, or the status code for its kind otherwise, as
+// returned by terr.KindOf. Errors with unknown or internal kinds map to
+// http.StatusInternalServerError.
+func StatusOf(err error) int {
+	if status, ok := terr.ValueOf(err, Status); ok {
+		return status
+	}
+	if status, ok := kindStatus[terr.KindOf(err)]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Responder writes the response for an error returned by an adapted handler,
+// with the status code returned by StatusOf. The error is always traced.
+type Responder func(w http.ResponseWriter, r *http.Request, err error, status int)
+
+// DefaultResponder writes the status code and its text as a plain text
+// response. Error messages are not included, as they may leak internal
+// details to clients.
+func DefaultResponder(w http.ResponseWriter, r *http.Request, err error, status int) {
+	text := http.StatusText(status)
+	if text == "" {
+		text = http.StatusText(http.StatusInternalServerError)
+	}
+	http.Error(w, text, status)
+}
+
+// Option customizes handlers returned by Adapt.
+type Option func(*handler)
+
+// WithResponder sets the Responder used to write responses for errors, which
+// is DefaultResponder by default. It can be used to render error pages or
+// API error objects, and to log or report errors.
+func WithResponder(responder Responder) Option {
+	return func(h *handler) {
+		h.responder = responder
+	}
+}
+
+// handler is an http.Handler calling a handler returning errors.
+type handler struct {
+	fn        func(http.ResponseWriter, *http.Request) error
+	responder Responder
+}
+
+// Adapt returns an http.Handler calling fn. Errors returned by fn are traced
+// at the adapter boundary, annotated with the "method" and "path" of the
+// request as metadata, as well as its "request_id" if set by RequestID, and
+// passed to the Responder set in opts along with their status code, as
+// returned by StatusOf. Handlers must not write to the response before
+// returning an error.
+func Adapt(fn func(http.ResponseWriter, *http.Request) error, opts ...Option) http.Handler {
+	h := &handler{fn: fn, responder: DefaultResponder}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := h.fn(w, r)
+	if err == nil {
+		return
+	}
+	opts := []terr.TraceOption{
+		terr.WithMetadata("method", r.Method),
+		terr.WithMetadata("path", r.URL.Path),
+	}
+	if id := RequestIDFrom(r.Context()); id != "" {
+		opts = append(opts, terr.WithMetadata("request_id", id))
+	}
+	err = terr.Trace(err, opts...)
+	h.responder(w, r, err, StatusOf(err))
+}
+
+// RequestIDHeader is the header from which RequestID takes request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey is the context key under which RequestID stores request IDs.
+// It can be registered with terr.SetContextKeys, so errors traced with
+// terr.WithContext include the request ID:
+//
+//	terr.SetContextKeys(map[string]any{"request_id": httphandler.RequestIDKey{}})
+type RequestIDKey struct{}
+
+// maxRequestIDLength is the maximum length of request IDs taken from requests.
+const maxRequestIDLength = 128
+
+// RequestID is a middleware storing the ID of each request in its context,
+// under RequestIDKey. The ID is taken from the RequestIDHeader header of the
+// request or, if it is missing or invalid, generated randomly. Valid IDs have
+// at most 128 characters among ASCII letters, digits, '-', '_', '.' and ':',
+// so IDs set by clients cannot inject content into responses or logs. The ID
+// is also set in the RequestIDHeader header of the response, so clients can
+// report it.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			var b [16]byte
+			rand.Read(b[:])
+			id = hex.EncodeToString(b[:])
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), RequestIDKey{}, id)))
+	})
+}
+
+// validRequestID returns whether id can be used as a request ID.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == ':') {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDFrom returns the request ID stored in ctx by RequestID, or an
+// empty string if there is none.
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,146 @@
+//go:build !terr_noop
+
+package httphandler_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+	"github.com/alnvdl/terr/httphandler"
+)
+
+func TestAdapt(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		body   string
+	}{
+		{nil, http.StatusOK, "ok"},
+		{errors.New("boom"), http.StatusInternalServerError, "Internal Server Error\n"},
+		{terr.Trace(errors.New("no user"), terr.WithKind(terr.NotFound)), http.StatusNotFound, "Not Found\n"},
+		{terr.Trace(errors.New("teapot"), terr.WithKind(terr.NotFound), terr.WithValue(httphandler.Status, http.StatusTeapot)), http.StatusTeapot, "I'm a teapot\n"},
+	}
+	for _, test := range tests {
+		h := httphandler.Adapt(func(w http.ResponseWriter, r *http.Request) error {
+			if test.err != nil {
+				return test.err
+			}
+			_, err := w.Write([]byte("ok"))
+			return err
+		})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+		if rec.Code != test.status || rec.Body.String() != test.body {
+			t.Fatalf("want %d %q, got %d %q", test.status, test.body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestWithResponder(t *testing.T) {
+	base := errors.New("no user")
+	var got error
+	h := httphandler.Adapt(func(w http.ResponseWriter, r *http.Request) error {
+		return terr.Trace(base, terr.WithKind(terr.NotFound))
+	}, httphandler.WithResponder(func(w http.ResponseWriter, r *http.Request, err error, status int) {
+		got = err
+		w.WriteHeader(status)
+		w.Write([]byte(`{"error":"` + terr.KindOf(err).String() + `"}`))
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/users/1", nil))
+
+	if rec.Code != http.StatusNotFound || rec.Body.String() != `{"error":"not_found"}` {
+		t.Fatalf("unexpected response: %d %q", rec.Code, rec.Body.String())
+	}
+	if !errors.Is(got, base) {
+		t.Fatalf("want error wrapping %v, got %v", base, got)
+	}
+	md := terr.Metadata(terr.TraceTree(got))
+	if md["method"] != http.MethodDelete || md["path"] != "/users/1" {
+		t.Fatalf("unexpected metadata: %v", md)
+	}
+	if children := terr.TraceTree(got).Children(); len(children) != 1 || !strings.HasSuffix(children[0].Error(), "no user") {
+		t.Fatalf("want the returned error as child, got %v", children)
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		kind   terr.Kind
+		status int
+	}{
+		{terr.Unknown, http.StatusInternalServerError},
+		{terr.Internal, http.StatusInternalServerError},
+		{terr.Invalid, http.StatusBadRequest},
+		{terr.Unauthenticated, http.StatusUnauthorized},
+		{terr.PermissionDenied, http.StatusForbidden},
+		{terr.Conflict, http.StatusConflict},
+		{terr.Timeout, http.StatusGatewayTimeout},
+		{terr.Canceled, httphandler.StatusClientClosedRequest},
+	}
+	for _, test := range tests {
+		if status := httphandler.StatusOf(terr.Trace(errors.New("fail"), terr.WithKind(test.kind))); status != test.status {
+			t.Fatalf("kind %v: want status %d, got %d", test.kind, test.status, status)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	terr.SetContextKeys(map[string]any{"request_id": httphandler.RequestIDKey{}})
+	defer terr.SetContextKeys(nil)
+
+	var adapted, inner error
+	h := httphandler.RequestID(httphandler.Adapt(func(w http.ResponseWriter, r *http.Request) error {
+		inner = terr.Trace(errors.New("no user"), terr.WithContext(r.Context()))
+		return inner
+	}, httphandler.WithResponder(func(w http.ResponseWriter, r *http.Request, err error, status int) {
+		adapted = err
+		httphandler.DefaultResponder(w, r, err, status)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(httphandler.RequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if id := rec.Header().Get(httphandler.RequestIDHeader); id != "req-1" {
+		t.Fatalf("want response request ID req-1, got %q", id)
+	}
+	if id := terr.Metadata(terr.TraceTree(adapted))["request_id"]; id != "req-1" {
+		t.Fatalf("want adapted error request ID req-1, got %v", id)
+	}
+	if id := terr.Metadata(terr.TraceTree(inner))["request_id"]; id != "req-1" {
+		t.Fatalf("want inner error request ID req-1, got %v", id)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	generated := rec.Header().Get(httphandler.RequestIDHeader)
+	if len(generated) != 32 {
+		t.Fatalf("want generated request ID, got %q", generated)
+	}
+	if id := terr.Metadata(terr.TraceTree(adapted))["request_id"]; id != generated {
+		t.Fatalf("want adapted error request ID %s, got %v", generated, id)
+	}
+
+	// Invalid IDs are replaced by generated ones.
+	for _, invalid := range []string{"req 1", "req-1\x1b[31m", "<script>", strings.Repeat("a", 129)} {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set(httphandler.RequestIDHeader, invalid)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if id := rec.Header().Get(httphandler.RequestIDHeader); len(id) != 32 || id == invalid {
+			t.Fatalf("want generated request ID for %q, got %q", invalid, id)
+		}
+	}
+	req = httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(httphandler.RequestIDHeader, strings.Repeat("a", 128))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if id := rec.Header().Get(httphandler.RequestIDHeader); id != strings.Repeat("a", 128) {
+		t.Fatalf("want request ID with maximum length, got %q", id)
+	}
+}
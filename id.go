@@ -0,0 +1,57 @@
+package terr
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+)
+
+// WithID assigns a unique ID to the traced error, which can be retrieved with
+// ID and is included when printing or emitting the error tracing tree. IDs
+// can be shown to users (e.g., in error pages), so they can be correlated
+// with the full error tracing trees in server logs. IDs can be assigned to
+// all traced errors with the IDs field of Config.
+//
+// IDs are ULIDs (https://github.com/ulid/spec), so they are sortable by
+// creation time. They are not considered when coalescing structurally
+// identical children, so only the ID of the first of them is shown.
+func WithID() TraceOption {
+	return func(e *tracedError) {
+		if e.id == "" {
+			e.id = newULID(time.Now())
+		}
+	}
+}
+
+// ID returns the ID of the first traced error in the Go error tree of err,
+// as assigned with WithID, or an empty string if it has none.
+func ID(err error) string {
+	if te := findTraced(err); te != nil {
+		return te.id
+	}
+	return ""
+}
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID returns a new ULID for time t, with random bits read from
+// crypto/rand.
+func newULID(t time.Time) string {
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], uint64(t.UnixMilli())<<16)
+	// crypto/rand.Read never fails on supported platforms.
+	rand.Read(id[6:])
+
+	// Encode the 128 bits as 26 characters of 5 bits each, with the first
+	// character holding only the 3 most significant bits.
+	var dst [26]byte
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	for i := 25; i >= 0; i-- {
+		dst[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(dst[:])
+}
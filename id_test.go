@@ -0,0 +1,50 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+var ulidPattern = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+
+func TestWithID(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Trace(errors.New("fail"), terr.WithID(), terr.WithMetadata("user", "alice"))
+
+	id := terr.ID(err)
+	assertEquals(t, ulidPattern.MatchString(id), true)
+	assertEquals(t, terr.ID(fmt.Errorf("wrapped: %w", err)), id)
+	assertEquals(t, fmt.Sprintf("%@", err), fmt.Sprintf("fail @ %s:%d [id=%s user=alice]", file, line+1, id))
+
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(b), fmt.Sprintf(`{"id":%q,"message":"fail","file":%q,"line":%d,"metadata":{"user":"alice"}}`,
+		id, file, line+1))
+
+	other := terr.Trace(errors.New("fail"), terr.WithID())
+	assertEquals(t, terr.ID(other) != id, true)
+
+	assertEquals(t, terr.ID(terr.Newf("fail")), "")
+	assertEquals(t, terr.ID(errors.New("fail")), "")
+	assertEquals(t, terr.ID(nil), "")
+}
+
+func TestConfigIDs(t *testing.T) {
+	terr.SetDefaults(terr.Config{IDs: true})
+	defer terr.SetDefaults(terr.Config{})
+
+	err := terr.Newf("fail")
+	assertEquals(t, ulidPattern.MatchString(terr.ID(err)), true)
+	assertEquals(t, terr.ID(terr.Trace(err)) != terr.ID(err), true)
+
+	terr.SetDefaults(terr.Config{})
+	err = terr.Trace(errors.New("fail"), terr.WithConfig(terr.Config{IDs: true}))
+	assertEquals(t, ulidPattern.MatchString(terr.ID(err)), true)
+}
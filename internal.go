@@ -0,0 +1,82 @@
+package terr
+
+import "sync/atomic"
+
+var internalFrames atomic.Pointer[func(file string) bool]
+
+// SetInternalFrames registers fn to classify the locations of traced errors
+// as internal plumbing (e.g., generated code or middleware layers), so they
+// can be hidden when printing error tracing trees:
+//
+//	terr.SetInternalFrames(func(file string) bool {
+//		return strings.Contains(file, "/middleware/") || strings.HasSuffix(file, "_gen.go")
+//	})
+//
+// Traced errors located in files for which fn returns true are not printed,
+// and their children are printed in their place. Internal traced errors
+// without children are root causes, so they are never hidden. Hidden traced
+// errors are still part of the error tracing tree, so they can be walked and
+// are included in structured output and in the text encoded by MarshalText.
+// They are also printed with the %+@ verb or when TreeStyle.Verbose is set.
+// Passing nil disables hiding.
+func SetInternalFrames(fn func(file string) bool) {
+	if fn == nil {
+		internalFrames.Store(nil)
+		return
+	}
+	internalFrames.Store(&fn)
+}
+
+// internalFilter returns the function registered with SetInternalFrames, or
+// nil if none was registered or style reveals internal traced errors.
+func internalFilter(style *TreeStyle) *func(string) bool {
+	if style.Verbose {
+		return nil
+	}
+	return internalFrames.Load()
+}
+
+// isHidden returns whether et must be hidden when printing error tracing
+// trees with internal as the internal frames filter.
+func isHidden(et ErrorTracer, internal func(string) bool) bool {
+	if len(childrenOf(et)) == 0 {
+		return false
+	}
+	file, _ := et.Location()
+	return internal(file)
+}
+
+// visibleChildren returns the children to be printed in place of children,
+// replacing the hidden ones by their own visible children. children is
+// returned as it is if none of them is hidden.
+func visibleChildren(children []ErrorTracer, internal func(string) bool) []ErrorTracer {
+	hidden := false
+	for _, child := range children {
+		if isHidden(child, internal) {
+			hidden = true
+			break
+		}
+	}
+	if !hidden {
+		return children
+	}
+
+	var visible []ErrorTracer
+	stack := make([]ErrorTracer, 0, len(children))
+	for i := len(children) - 1; i >= 0; i-- {
+		stack = append(stack, children[i])
+	}
+	for len(stack) > 0 {
+		et := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !isHidden(et, internal) {
+			visible = append(visible, et)
+			continue
+		}
+		grandchildren := childrenOf(et)
+		for i := len(grandchildren) - 1; i >= 0; i-- {
+			stack = append(stack, grandchildren[i])
+		}
+	}
+	return visible
+}
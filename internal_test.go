@@ -0,0 +1,52 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestSetInternalFrames(t *testing.T) {
+	file, line := getLocation(0)
+	leaf := terr.Newf("leaf")
+	plumbing := terr.Newf("middleware: %w", leaf)
+	internalLeaf := terr.Newf("internal leaf")
+	err := terr.Newf("handler: %w, %w", plumbing, internalLeaf)
+	full := strings.Join([]string{
+		fmt.Sprintf("handler: middleware: leaf, internal leaf @ %s:%d", file, line+4),
+		fmt.Sprintf("\tmiddleware: leaf @ %s:%d", file, line+2),
+		fmt.Sprintf("\t\tleaf @ %s:%d", file, line+1),
+		fmt.Sprintf("\tinternal leaf @ %s:%d", file, line+3),
+	}, "\n")
+	assertEquals(t, fmt.Sprintf("%@", err), full)
+
+	terr.SetInternalFrames(func(file string) bool {
+		return strings.HasSuffix(file, "internal_test.go")
+	})
+	defer terr.SetInternalFrames(nil)
+
+	// The root and leaves are never hidden.
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("handler: middleware: leaf, internal leaf @ %s:%d", file, line+4),
+		fmt.Sprintf("\tleaf @ %s:%d", file, line+1),
+		fmt.Sprintf("\tinternal leaf @ %s:%d", file, line+3),
+	}, "\n"))
+	fn := " in github.com/alnvdl/terr_test.TestSetInternalFrames"
+	assertEquals(t, fmt.Sprintf("%+@", err), strings.Join([]string{
+		fmt.Sprintf("handler: middleware: leaf, internal leaf @ %s:%d%s", file, line+4, fn),
+		fmt.Sprintf("\tmiddleware: leaf @ %s:%d%s", file, line+2, fn),
+		fmt.Sprintf("\t\tleaf @ %s:%d%s", file, line+1, fn),
+		fmt.Sprintf("\tinternal leaf @ %s:%d%s", file, line+3, fn),
+	}, "\n"))
+	assertEquals(t, len(terr.TraceTree(err).Children()), 2)
+	text, _ := err.(interface{ MarshalText() ([]byte, error) }).MarshalText()
+	assertEquals(t, string(text), full)
+
+	terr.SetTreeStyle(terr.TreeStyle{Verbose: true})
+	defer terr.SetTreeStyle(terr.TreeStyle{})
+	assertEquals(t, fmt.Sprintf("%@", err), full)
+}
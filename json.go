@@ -0,0 +1,247 @@
+package terr
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// MarshalJSON implements json.Marshaler, encoding the error tracing tree
+// rooted in e as nested JSON objects. The keys used can be configured with
+// SetFieldNames.
+func (e *tracedError) MarshalJSON() ([]byte, error) {
+	e.report()
+	return appendJSON(nil, e, defaultExportConfig(), 1), nil
+}
+
+// appendJSON appends the JSON representation of the error tracing tree rooted
+// in et, which was repeated count times, to dst, returning the extended
+// buffer. Structurally identical children are encoded only once, along with
+// the number of times they were repeated. The frames shared by the call
+// stacks of children are encoded only once in their parent. Nodes are kept in
+// an explicit stack, so arbitrarily deep trees cannot overflow the goroutine
+// stack.
+func appendJSON(dst []byte, et ErrorTracer, cfg *exportConfig, count int) []byte {
+	// jsonFrame is a node whose children are being appended.
+	type jsonFrame struct {
+		et     ErrorTracer
+		groups []childGroup
+		stacks [][]string
+		next   int
+	}
+	service := cfg.service
+	stack := stackFrames(et, cfg.path)
+	var frames []jsonFrame
+	for {
+		dst = appendJSONFields(dst, et, cfg, count, service)
+		service = nil
+		if stack != nil {
+			dst = appendJSONStack(dst, cfg.names.Stack, stack)
+		}
+		groups := groupChildren(nil, sortedChildren(childrenOf(et), cfg.order))
+		shared, stacks := sharedStack(groups, cfg.path)
+		if shared != nil {
+			dst = appendJSONStack(dst, cfg.names.SharedStack, shared)
+		}
+		if len(groups) > 0 {
+			dst = append(dst, ',')
+			dst = appendJSONString(dst, cfg.names.Children)
+			dst = append(dst, ":["...)
+		}
+		frames = append(frames, jsonFrame{et: et, groups: groups, stacks: stacks})
+
+		// Find the next group of children to append, closing the nodes that
+		// have none left.
+		for len(frames) > 0 {
+			f := &frames[len(frames)-1]
+			if f.next < len(f.groups) {
+				break
+			}
+			if len(f.groups) > 0 {
+				dst = append(dst, ']')
+			}
+			if omitted := OmittedChildren(f.et); omitted > 0 {
+				dst = append(dst, ',')
+				dst = appendJSONString(dst, cfg.names.Omitted)
+				dst = append(dst, ':')
+				dst = strconv.AppendInt(dst, int64(omitted), 10)
+			}
+			dst = append(dst, '}')
+			frames = frames[:len(frames)-1]
+		}
+		if len(frames) == 0 {
+			return dst
+		}
+
+		f := &frames[len(frames)-1]
+		if f.next > 0 {
+			dst = append(dst, ',')
+		}
+		et, count = f.groups[f.next].ErrorTracer, f.groups[f.next].count
+		stack = nil
+		if f.stacks != nil {
+			stack = f.stacks[f.next]
+		}
+		f.next++
+	}
+}
+
+// MarshalJSON returns the JSON representation of the error tracing tree for
+// err, as json.Marshal does for traced errors, so logs can be shipped as JSON
+// without walking error tracing trees in every service. Like TraceTree, it
+// also finds traced errors wrapped by non-traced errors, in which case the
+// tree of the nearest traced error is returned. Errors without traced errors
+// are represented as objects with only their message, and nil as null. The
+// returned error is always nil, and it is only returned for symmetry with
+// json.Marshal.
+func MarshalJSON(err error) ([]byte, error) {
+	if err == nil {
+		return []byte("null"), nil
+	}
+	if te := findTraced(err); te != nil {
+		return te.MarshalJSON()
+	}
+	return appendMessageJSON(nil, err), nil
+}
+
+// appendMessageJSON appends an object with only the message of err to dst,
+// returning the extended buffer.
+func appendMessageJSON(dst []byte, err error) []byte {
+	dst = append(dst, '{')
+	dst = appendJSONString(dst, getFieldNames().Message)
+	dst = append(dst, ':')
+	dst = appendJSONString(dst, err.Error())
+	return append(dst, '}')
+}
+
+// appendJSONFields appends the opening brace and the fields of et, which was
+// repeated count times, to dst, except for its children and omitted
+// children. service is included if it is not nil.
+func appendJSONFields(dst []byte, et ErrorTracer, cfg *exportConfig, count int, service *ServiceInfo) []byte {
+	names := cfg.names
+	file, line := et.Location()
+	if cfg.path != nil {
+		file = cfg.path(file)
+	}
+	message, metadata := et.Error(), metadataOf(et)
+	if cfg.redact || isRedacted(et) {
+		message, metadata = redactedMessage(et), nil
+	}
+	dst = append(dst, '{')
+	if te, ok := et.(*tracedError); ok && te.id != "" && !cfg.canonical {
+		dst = appendJSONString(dst, names.ID)
+		dst = append(dst, ':')
+		dst = appendJSONString(dst, te.id)
+		dst = append(dst, ',')
+	}
+	dst = appendJSONString(dst, names.Message)
+	dst = append(dst, ':')
+	dst = appendJSONString(dst, message)
+	if file != "" {
+		dst = append(dst, ',')
+		if names.Location != "" {
+			dst = appendJSONString(dst, names.Location)
+			dst = append(dst, ":{"...)
+		}
+		dst = appendJSONString(dst, names.File)
+		dst = append(dst, ':')
+		dst = appendJSONString(dst, file)
+		dst = append(dst, ',')
+		dst = appendJSONString(dst, names.Line)
+		dst = append(dst, ':')
+		dst = strconv.AppendInt(dst, int64(line), 10)
+		if names.Location != "" {
+			dst = append(dst, '}')
+		}
+	}
+	if te, ok := et.(*tracedError); ok {
+		if code := te.fullCode(); code != "" {
+			dst = append(dst, ',')
+			dst = appendJSONString(dst, names.Code)
+			dst = append(dst, ':')
+			dst = appendJSONString(dst, code)
+		}
+		if te.kind != Unknown {
+			dst = append(dst, ',')
+			dst = appendJSONString(dst, names.Kind)
+			dst = append(dst, ':')
+			dst = appendJSONString(dst, te.kind.String())
+		}
+		if te.domain != "" {
+			dst = append(dst, ',')
+			dst = appendJSONString(dst, names.Domain)
+			dst = append(dst, ':')
+			dst = appendJSONString(dst, te.domain)
+		}
+		if op := opOf(te); op != "" {
+			dst = append(dst, ',')
+			dst = appendJSONString(dst, names.Op)
+			dst = append(dst, ':')
+			dst = appendJSONString(dst, op)
+		}
+		if len(te.tags) > 0 {
+			dst = append(dst, ',')
+			dst = appendJSONString(dst, names.Tags)
+			dst = append(dst, ":["...)
+			for i, tag := range te.tags {
+				if i > 0 {
+					dst = append(dst, ',')
+				}
+				dst = appendJSONString(dst, tag)
+			}
+			dst = append(dst, ']')
+		}
+	}
+	if hints := hintsOf(et); len(hints) > 0 {
+		dst = append(dst, ',')
+		dst = appendJSONString(dst, names.Hints)
+		dst = append(dst, ":["...)
+		for i, hint := range hints {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, hint)
+		}
+		dst = append(dst, ']')
+	}
+	if te, ok := et.(*tracedError); ok {
+		if url := te.getURL(); url != "" {
+			dst = append(dst, ',')
+			dst = appendJSONString(dst, names.URL)
+			dst = append(dst, ':')
+			dst = appendJSONString(dst, url)
+		}
+	}
+	if te, ok := et.(*tracedError); ok && !te.time.IsZero() {
+		dst = append(dst, ',')
+		dst = appendJSONString(dst, names.Time)
+		dst = append(dst, ':')
+		dst = appendJSONString(dst, te.time.Format(time.RFC3339Nano))
+		dst = append(dst, ',')
+		dst = appendJSONString(dst, names.Imported)
+		dst = append(dst, ":true"...)
+	}
+	if len(metadata) > 0 {
+		dst = append(dst, ',')
+		dst = appendJSONString(dst, names.Metadata)
+		dst = append(dst, ':')
+		dst = appendMetadataJSON(dst, metadata)
+	}
+	if count > 1 {
+		dst = append(dst, ',')
+		dst = appendJSONString(dst, names.Count)
+		dst = append(dst, ':')
+		dst = strconv.AppendInt(dst, int64(count), 10)
+	}
+	if service != nil {
+		dst = appendServiceJSON(dst, service, names)
+	}
+	return dst
+}
+
+// appendJSONString appends s to dst as a JSON string.
+func appendJSONString(dst []byte, s string) []byte {
+	// Marshaling a string never fails.
+	b, _ := json.Marshal(s)
+	return append(dst, b...)
+}
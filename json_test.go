@@ -0,0 +1,82 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Newf("fail \"quoted\"")
+	tracedErr := terr.Trace(err)
+
+	b, jsonErr := json.Marshal(tracedErr)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(b), fmt.Sprintf(`{"message":"fail \"quoted\"","file":%q,"line":%d,"children":[`+
+		`{"message":"fail \"quoted\"","file":%q,"line":%d}]}`,
+		file, line+2, file, line+1))
+}
+
+func TestSetFieldNames(t *testing.T) {
+	terr.SetFieldNames(terr.FieldNames{Message: "msg", Children: "causes"})
+	defer terr.SetFieldNames(terr.FieldNames{})
+
+	file, line := getLocation(0)
+	err := terr.Trace(terr.Newf("fail"))
+
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(b), fmt.Sprintf(`{"msg":"fail","file":%q,"line":%d,"causes":[`+
+		`{"msg":"fail","file":%q,"line":%d}]}`,
+		file, line+1, file, line+1))
+}
+
+func TestFieldNamesProfiles(t *testing.T) {
+	defer terr.SetFieldNames(terr.FieldNames{})
+
+	file, line := getLocation(0)
+	err := terr.Trace(errors.New("fail"), terr.WithCode("failed"), terr.WithMetadata("user", "alice"))
+
+	terr.SetFieldNames(terr.ECSFieldNames)
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(b), fmt.Sprintf(`{"error.message":"fail","log.origin.file.name":%q,"log.origin.file.line":%d,`+
+		`"error.code":"failed","labels":{"user":"alice"}}`, file, line+1))
+
+	terr.SetFieldNames(terr.OTelFieldNames)
+	b, jsonErr = json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(b), fmt.Sprintf(`{"exception.message":"fail","code.filepath":%q,"code.lineno":%d,`+
+		`"error.type":"failed","attributes":{"user":"alice"}}`, file, line+1))
+
+	terr.SetFieldNames(terr.GCPFieldNames)
+	b, jsonErr = json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(b), fmt.Sprintf(`{"message":"fail","logging.googleapis.com/sourceLocation":{"file":%q,"line":%d},`+
+		`"code":"failed","labels":{"user":"alice"}}`, file, line+1))
+}
+
+func TestMarshalJSONFunc(t *testing.T) {
+	file, line := getLocation(0)
+	traced := terr.Newf("fail")
+	want := fmt.Sprintf(`{"message":"fail","file":%q,"line":%d}`, file, line+1)
+
+	b, err := terr.MarshalJSON(traced)
+	assertErrorIsNil(t, err)
+	assertEquals(t, string(b), want)
+	b, err = terr.MarshalJSON(fmt.Errorf("wrapped: %w", traced))
+	assertErrorIsNil(t, err)
+	assertEquals(t, string(b), want)
+	b, err = terr.MarshalJSON(errors.New("plain"))
+	assertErrorIsNil(t, err)
+	assertEquals(t, string(b), `{"message":"plain"}`)
+	b, err = terr.MarshalJSON(nil)
+	assertErrorIsNil(t, err)
+	assertEquals(t, string(b), "null")
+}
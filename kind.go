@@ -0,0 +1,125 @@
+package terr
+
+// Kind classifies errors by their nature, enabling uniform handling at API
+// boundaries, such as mapping errors to HTTP status codes.
+type Kind uint8
+
+// Kinds of errors.
+const (
+	// Unknown means the kind of an error is unknown. It is the zero Kind.
+	Unknown Kind = iota
+	// NotFound means an entity was not found.
+	NotFound
+	// AlreadyExists means an entity already exists.
+	AlreadyExists
+	// PermissionDenied means the caller is not allowed to perform an
+	// operation.
+	PermissionDenied
+	// Unauthenticated means the caller could not be authenticated.
+	Unauthenticated
+	// Invalid means an argument or input is invalid.
+	Invalid
+	// Conflict means an operation conflicts with the current state.
+	Conflict
+	// Unavailable means a dependency is temporarily unavailable.
+	Unavailable
+	// Timeout means an operation did not finish in time.
+	Timeout
+	// Canceled means an operation was canceled.
+	Canceled
+	// Internal means an unexpected internal error.
+	Internal
+)
+
+var kindNames = [...]string{
+	Unknown:          "unknown",
+	NotFound:         "not_found",
+	AlreadyExists:    "already_exists",
+	PermissionDenied: "permission_denied",
+	Unauthenticated:  "unauthenticated",
+	Invalid:          "invalid",
+	Conflict:         "conflict",
+	Unavailable:      "unavailable",
+	Timeout:          "timeout",
+	Canceled:         "canceled",
+	Internal:         "internal",
+}
+
+// String returns the name of k, such as "not_found".
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown"
+}
+
+// WithKind sets the kind of the traced error.
+func WithKind(kind Kind) TraceOption {
+	return func(e *tracedError) {
+		e.kind = kind
+	}
+}
+
+// KindOf returns the kind of err, which is the kind of the innermost traced
+// error with a kind in its error tracing tree, so the most specific
+// classification wins over broader ones set by callers. If traced errors at
+// the same depth have kinds, the first one in depth-first order wins. The
+// error tracing tree is searched for in the Go error tree of err. Returns
+// Unknown if no traced error has a kind.
+func KindOf(err error) Kind {
+	kind := Unknown
+	walkErrors(err, func(err error) bool {
+		te, ok := err.(*tracedError)
+		if !ok {
+			return true
+		}
+		kind = innermostKind(te)
+		return false
+	})
+	return kind
+}
+
+// innermostKind returns the kind of the innermost traced error with a kind
+// in the error tracing tree rooted in et, or Unknown if no traced error has a
+// kind. If traced errors at the same depth have kinds, the first one in
+// depth-first order wins.
+func innermostKind(et ErrorTracer) Kind {
+	type node struct {
+		et    ErrorTracer
+		depth int
+	}
+	kind, kindDepth := Unknown, -1
+	stack := []node{{et, 0}}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if te, ok := n.et.(*tracedError); ok && te.kind != Unknown && n.depth > kindDepth {
+			kind, kindDepth = te.kind, n.depth
+		}
+		children := childrenOf(n.et)
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, node{children[i], n.depth + 1})
+		}
+	}
+	return kind
+}
+
+// IsKind returns whether any traced error in the error tracing tree for err
+// has the given kind. Unlike KindOf, which returns only the innermost kind,
+// broader kinds set by callers are also considered. The error tracing tree is
+// searched for in the Go error tree of err.
+func IsKind(err error, kind Kind) bool {
+	return anyTraced(err, func(te *tracedError) bool {
+		return te.kind == kind
+	})
+}
+
+// parseKind returns the Kind named name, or Unknown if there is none.
+func parseKind(name string) Kind {
+	for k, n := range kindNames {
+		if n == name {
+			return Kind(k)
+		}
+	}
+	return Unknown
+}
@@ -0,0 +1,58 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestKindOf(t *testing.T) {
+	notFound := terr.Trace(errors.New("no rows"), terr.WithKind(terr.NotFound))
+	err := terr.Trace(notFound, terr.WithKind(terr.Internal))
+	wrapped := fmt.Errorf("lookup failed: %w", err)
+
+	assertEquals(t, terr.KindOf(wrapped), terr.NotFound)
+	assertEquals(t, terr.KindOf(err), terr.NotFound)
+	assertEquals(t, terr.KindOf(terr.Trace(errors.New("fail"), terr.WithKind(terr.Invalid))), terr.Invalid)
+	assertEquals(t, terr.KindOf(terr.Newf("fail")), terr.Unknown)
+	assertEquals(t, terr.KindOf(errors.New("fail")), terr.Unknown)
+	assertEquals(t, terr.KindOf(nil), terr.Unknown)
+}
+
+func TestKindOfSiblings(t *testing.T) {
+	denied := terr.Trace(errors.New("denied"), terr.WithKind(terr.PermissionDenied))
+	deep := terr.Trace(terr.Trace(errors.New("timeout"), terr.WithKind(terr.Timeout)))
+	invalid := terr.Trace(errors.New("invalid"), terr.WithKind(terr.Invalid))
+
+	// The innermost kind wins, even if it is not in the first child.
+	assertEquals(t, terr.KindOf(terr.Newf("%v, %v", denied, deep)), terr.Timeout)
+	// Kinds at the same depth are resolved in depth-first order.
+	assertEquals(t, terr.KindOf(terr.Newf("%v, %v", denied, invalid)), terr.PermissionDenied)
+}
+
+func TestKindString(t *testing.T) {
+	assertEquals(t, terr.NotFound.String(), "not_found")
+	assertEquals(t, terr.Unknown.String(), "unknown")
+	assertEquals(t, terr.Kind(200).String(), "unknown")
+
+	b, jsonErr := json.Marshal(terr.Trace(errors.New("fail"), terr.WithKind(terr.Conflict)))
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, strings.Contains(string(b), `"kind":"conflict"`), true)
+}
+
+func TestIsKind(t *testing.T) {
+	notFound := terr.Trace(errors.New("no rows"), terr.WithKind(terr.NotFound))
+	err := terr.Trace(notFound, terr.WithKind(terr.Internal))
+	wrapped := fmt.Errorf("lookup failed: %w", err)
+
+	assertEquals(t, terr.IsKind(wrapped, terr.NotFound), true)
+	assertEquals(t, terr.IsKind(wrapped, terr.Internal), true)
+	assertEquals(t, terr.IsKind(wrapped, terr.Invalid), false)
+	assertEquals(t, terr.IsKind(errors.New("fail"), terr.NotFound), false)
+}
@@ -0,0 +1,72 @@
+package terr
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// maxChildren is the maximum number of children recorded per traced error.
+// Zero means no limit.
+var maxChildren atomic.Int64
+
+// SetMaxChildren limits the number of children recorded by each traced error
+// created from now on to max. Children beyond the limit are not included in
+// the error tracing tree, but they are counted, and the count is included
+// when printing or emitting the tree. This prevents unbounded memory usage
+// when errors are accidentally traced in large loops. A limit of zero or less
+// (the default) disables the limit.
+func SetMaxChildren(max int) {
+	if max < 0 {
+		max = 0
+	}
+	maxChildren.Store(int64(max))
+}
+
+// OmittedChildren returns the number of children that were not recorded in
+// et due to the limit set with SetMaxChildren.
+func OmittedChildren(et ErrorTracer) int {
+	if te, ok := et.(*tracedError); ok {
+		return te.omitted
+	}
+	return 0
+}
+
+// pathDepth is the number of trailing elements kept in the file paths of
+// locations. Zero means full paths are kept.
+var pathDepth atomic.Int64
+
+// SetPathDepth sets the number of trailing elements kept in the file paths
+// recorded as the locations of traced errors, so 1 keeps only base names
+// (e.g., "conn.go") and 2 also keeps their directories (e.g., "db/conn.go").
+// Paths are trimmed when locations are first resolved, once per call site and
+// before any Config.Location transformation, so shorter paths are printed and
+// emitted without transforming them in every exporter. A depth of zero or
+// less (the default) keeps full paths. It is meant to be called once during program initialization,
+// since traced errors whose locations were already resolved keep them.
+func SetPathDepth(depth int) {
+	if depth < 0 {
+		depth = 0
+	}
+	pathDepth.Store(int64(depth))
+	locations.Range(func(pc, _ any) bool {
+		locations.Delete(pc)
+		return true
+	})
+}
+
+// trimPath returns the trailing elements of the slash-separated file path,
+// as set with SetPathDepth.
+func trimPath(file string) string {
+	depth := pathDepth.Load()
+	if depth == 0 {
+		return file
+	}
+	end := len(file)
+	for ; depth > 0 && end > 0; depth-- {
+		end = strings.LastIndexByte(file[:end], '/')
+		if end < 0 {
+			return file
+		}
+	}
+	return file[end+1:]
+}
@@ -0,0 +1,57 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestSetMaxChildren(t *testing.T) {
+	terr.SetMaxChildren(2)
+	defer terr.SetMaxChildren(0)
+
+	file, line := getLocation(0)
+	err1 := terr.Newf("err1")
+	err2 := terr.Newf("err2")
+	err3 := terr.Newf("err3")
+	err := terr.Newf("%v, %v, %v", err1, err2, err3)
+
+	et := terr.TraceTree(err)
+	assertEquals(t, len(et.Children()), 2)
+	assertEquals(t, terr.OmittedChildren(et), 1)
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("err1, err2, err3 @ %s:%d", file, line+4),
+		fmt.Sprintf("\terr1 @ %s:%d", file, line+1),
+		fmt.Sprintf("\terr2 @ %s:%d", file, line+2),
+		"\t(1 more children omitted)",
+	}, "\n"))
+
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, strings.HasSuffix(string(b), `],"omitted":1}`), true)
+
+	terr.SetMaxChildren(0)
+	err = terr.Newf("%v, %v, %v", err1, err2, err3)
+	assertEquals(t, len(terr.TraceTree(err).Children()), 3)
+	assertEquals(t, terr.OmittedChildren(terr.TraceTree(err)), 0)
+}
+
+func TestSetPathDepth(t *testing.T) {
+	defer terr.SetPathDepth(0)
+	file, line := getLocation(0)
+	newErr := func() error { return terr.Newf("fail") }
+
+	terr.SetPathDepth(1)
+	assertEquals(t, terr.Sprint(newErr()), fmt.Sprintf("fail @ %s:%d", filepath.Base(file), line+1))
+	terr.SetPathDepth(2)
+	assertEquals(t, terr.Sprint(newErr()), fmt.Sprintf("fail @ %s:%d",
+		filepath.Base(filepath.Dir(file))+"/"+filepath.Base(file), line+1))
+	terr.SetPathDepth(0)
+	assertEquals(t, terr.Sprint(newErr()), fmt.Sprintf("fail @ %s:%d", file, line+1))
+}
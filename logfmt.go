@@ -0,0 +1,130 @@
+package terr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ExportLogfmt returns the error tracing tree for err in the logfmt format,
+// customized by opts as in Export, with one line per traced error, so it can
+// flow through logfmt-only log pipelines. Each line identifies its traced
+// error with the node key, numbered in depth-first order starting at 0 for
+// the root, and its parent with the parent key, so the tree can be
+// reassembled:
+//
+//	node=0 depth=0 msg="query: timeout" file=/src/api.go line=10
+//	node=1 parent=0 depth=1 msg=timeout file=/src/db.go line=20 table=users
+//
+// Codes, kinds, operations, repetition counts and metadata are also included,
+// and so are the host, environment and labels set with SetServiceInfo in the
+// line of the root.
+// Returns nil if err is not a traced error.
+func ExportLogfmt(err error, opts ...ExportOption) []byte {
+	te, ok := err.(*tracedError)
+	if !ok || te == nil {
+		return nil
+	}
+	cfg := defaultExportConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	te.report()
+	return appendLogfmt(nil, te, cfg)
+}
+
+// appendLogfmt appends the logfmt lines for the error tracing tree rooted in
+// et to dst, numbering nodes in depth-first order. Nodes are kept in an
+// explicit stack, so arbitrarily deep trees cannot overflow the goroutine
+// stack.
+func appendLogfmt(dst []byte, et ErrorTracer, cfg *exportConfig) []byte {
+	type logfmtNode struct {
+		group         childGroup
+		parent, depth int
+	}
+	stack := []logfmtNode{{childGroup{ErrorTracer: et, count: 1}, -1, 0}}
+	for node := 0; len(stack) > 0; node++ {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		dst = appendLogfmtLine(dst, n.group.ErrorTracer, cfg, n.parent, node, n.depth, n.group.count)
+		groups := groupChildren(nil, childrenOf(n.group.ErrorTracer))
+		for i := len(groups) - 1; i >= 0; i-- {
+			stack = append(stack, logfmtNode{groups[i], node, n.depth + 1})
+		}
+	}
+	return dst
+}
+
+// appendLogfmtLine appends the logfmt line for et, which was repeated count
+// times, to dst. node is the number of et, and parent the number of its
+// parent, or -1 if it is the root.
+func appendLogfmtLine(dst []byte, et ErrorTracer, cfg *exportConfig, parent, node, depth, count int) []byte {
+	file, line := et.Location()
+	if cfg.path != nil {
+		file = cfg.path(file)
+	}
+	message, metadata := et.Error(), metadataOf(et)
+	if cfg.redact || isRedacted(et) {
+		message, metadata = redactedMessage(et), nil
+	}
+	dst = append(dst, "node="...)
+	dst = strconv.AppendInt(dst, int64(node), 10)
+	if parent >= 0 {
+		dst = append(dst, " parent="...)
+		dst = strconv.AppendInt(dst, int64(parent), 10)
+	}
+	dst = append(dst, " depth="...)
+	dst = strconv.AppendInt(dst, int64(depth), 10)
+	dst = append(dst, " msg="...)
+	dst = appendTextValue(dst, message)
+	if file != "" {
+		dst = append(dst, " file="...)
+		dst = appendTextValue(dst, file)
+		dst = append(dst, " line="...)
+		dst = strconv.AppendInt(dst, int64(line), 10)
+	}
+	if te, ok := et.(*tracedError); ok {
+		if code := te.fullCode(); code != "" {
+			dst = append(dst, " code="...)
+			dst = appendTextValue(dst, code)
+		}
+		if te.kind != Unknown {
+			dst = append(dst, " kind="...)
+			dst = append(dst, te.kind.String()...)
+		}
+		if op := opOf(te); op != "" {
+			dst = append(dst, " op="...)
+			dst = appendTextValue(dst, op)
+		}
+	}
+	if count > 1 {
+		dst = append(dst, " count="...)
+		dst = strconv.AppendInt(dst, int64(count), 10)
+	}
+	if omitted := OmittedChildren(et); omitted > 0 {
+		dst = append(dst, " omitted="...)
+		dst = strconv.AppendInt(dst, int64(omitted), 10)
+	}
+	for _, md := range metadata {
+		dst = append(dst, ' ')
+		dst = append(dst, md.key...)
+		dst = append(dst, '=')
+		dst = appendTextValue(dst, fmt.Sprint(md.value))
+	}
+	if info := cfg.service; info != nil && parent < 0 {
+		if info.Host != "" {
+			dst = append(dst, " host="...)
+			dst = appendTextValue(dst, info.Host)
+		}
+		if info.Environment != "" {
+			dst = append(dst, " environment="...)
+			dst = appendTextValue(dst, info.Environment)
+		}
+		for _, k := range info.sortedLabels() {
+			dst = append(dst, ' ')
+			dst = append(dst, k...)
+			dst = append(dst, '=')
+			dst = appendTextValue(dst, info.Labels[k])
+		}
+	}
+	return append(dst, '\n')
+}
@@ -0,0 +1,27 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestExportLogfmt(t *testing.T) {
+	file, line := getLocation(0)
+	leaf := terr.Trace(errors.New("timeout"), terr.WithMetadata("table", "users"), terr.WithKind(terr.Timeout))
+	other := terr.Newf("other")
+	err := terr.Newf("query: %w %w %w", leaf, other, other)
+
+	assertEquals(t, string(terr.ExportLogfmt(err)), strings.Join([]string{
+		fmt.Sprintf(`node=0 depth=0 msg="query: timeout other other" file=%s line=%d`, file, line+3),
+		fmt.Sprintf(`node=1 parent=0 depth=1 msg=timeout file=%s line=%d kind=timeout table=users`, file, line+1),
+		fmt.Sprintf(`node=2 parent=0 depth=1 msg=other file=%s line=%d count=2`, file, line+2),
+		"",
+	}, "\n"))
+	assertEquals(t, terr.ExportLogfmt(errors.New("fail")) == nil, true)
+}
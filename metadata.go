@@ -0,0 +1,134 @@
+package terr
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// metadatum is a key-value pair attached to a traced error.
+type metadatum struct {
+	key   string
+	value any
+}
+
+// setMetadata sets key to value in the metadata of e, replacing any value
+// previously set for key.
+func (e *tracedError) setMetadata(key string, value any) {
+	for i := range e.metadata {
+		if e.metadata[i].key == key {
+			e.metadata[i].value = value
+			return
+		}
+	}
+	e.metadata = append(e.metadata, metadatum{key, value})
+}
+
+// WithMetadata attaches a key-value pair to the traced error. Metadata is
+// included when printing or emitting error tracing trees, but it does not
+// affect their fingerprints. Setting the same key more than once keeps only
+// the last value.
+func WithMetadata(key string, value any) TraceOption {
+	return func(e *tracedError) {
+		e.setMetadata(key, value)
+	}
+}
+
+// Metadata returns the metadata attached to et, or nil if there is none.
+func Metadata(et ErrorTracer) map[string]any {
+	te, ok := et.(*tracedError)
+	if !ok || len(te.metadata) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(te.metadata))
+	for _, md := range te.metadata {
+		m[md.key] = md.value
+	}
+	return m
+}
+
+// metadataOf returns the metadata attached to et, in the order it was set.
+func metadataOf(et ErrorTracer) []metadatum {
+	if te, ok := et.(*tracedError); ok {
+		return te.metadata
+	}
+	return nil
+}
+
+// sameMetadata returns whether a and b have the same metadata.
+func sameMetadata(a, b ErrorTracer) bool {
+	return reflect.DeepEqual(metadataOf(a), metadataOf(b))
+}
+
+// appendMetadataText appends the ID and metadata of et to dst in the
+// " [key=value]" format, with values quoted if needed. Nothing is appended if
+// et has neither an ID nor metadata.
+func appendMetadataText(dst []byte, et ErrorTracer) []byte {
+	metadata := metadataOf(et)
+	var id string
+	if te, ok := et.(*tracedError); ok {
+		id = te.id
+	}
+	if id == "" && len(metadata) == 0 {
+		return dst
+	}
+	dst = append(dst, " ["...)
+	if id != "" {
+		dst = append(dst, "id="...)
+		dst = append(dst, id...)
+	}
+	for i, md := range metadata {
+		if i > 0 || id != "" {
+			dst = append(dst, ' ')
+		}
+		dst = append(dst, md.key...)
+		dst = append(dst, '=')
+		dst = appendTextValue(dst, fmt.Sprint(md.value))
+	}
+	return append(dst, ']')
+}
+
+// appendTextValue appends value to dst as the value of a key=value pair,
+// quoted if needed.
+func appendTextValue(dst []byte, value string) []byte {
+	if value == "" || strings.ContainsAny(value, " =\"[]\t\n") {
+		return strconv.AppendQuote(dst, value)
+	}
+	return append(dst, value...)
+}
+
+// appendMetadataJSON appends metadata to dst as a JSON object.
+func appendMetadataJSON(dst []byte, metadata []metadatum) []byte {
+	dst = append(dst, '{')
+	for i, md := range metadata {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, md.key)
+		dst = append(dst, ':')
+		dst = appendJSONValue(dst, md.value)
+	}
+	return append(dst, '}')
+}
+
+// appendJSONValue appends v to dst as a JSON value. Values implementing
+// json.Marshaler are encoded by their own method, fmt.Stringer and error
+// values are encoded as strings, and all other values are encoded by
+// json.Marshal. Values that cannot be encoded are encoded as strings, as
+// formatted by fmt.Sprint.
+func appendJSONValue(dst []byte, v any) []byte {
+	switch v := v.(type) {
+	case json.Marshaler:
+	case fmt.Stringer:
+		return appendJSONString(dst, v.String())
+	case error:
+		return appendJSONString(dst, v.Error())
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return appendJSONString(dst, fmt.Sprint(v))
+	}
+	return append(dst, b...)
+}
@@ -0,0 +1,45 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestWithMetadata(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Trace(terr.Newf("fail"),
+		terr.WithMetadata("attempt", 3),
+		terr.WithMetadata("user", "john doe"),
+		terr.WithMetadata("attempt", 4),
+		terr.WithMetadata("elapsed", 1500*time.Millisecond))
+
+	md := terr.Metadata(terr.TraceTree(err))
+	assertEquals(t, len(md), 3)
+	assertEquals(t, md["attempt"], any(4))
+	assertEquals(t, md["user"], any("john doe"))
+	assertEquals(t, terr.Metadata(terr.TraceTree(err).Children()[0]) == nil, true)
+
+	assertEquals(t, fmt.Sprintf("%@", err), fmt.Sprintf(
+		"fail @ %s:%d [attempt=4 user=\"john doe\" elapsed=1.5s]\n\tfail @ %s:%d",
+		file, line+1, file, line+1))
+
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(b), fmt.Sprintf(`{"message":"fail","file":%q,"line":%d,`+
+		`"metadata":{"attempt":4,"user":"john doe","elapsed":"1.5s"},"children":[`+
+		`{"message":"fail","file":%q,"line":%d}]}`,
+		file, line+1, file, line+1))
+}
+
+func TestMetadataFingerprint(t *testing.T) {
+	newErr := func(attempt int) error {
+		return terr.Trace(terr.Newf("fail"), terr.WithMetadata("attempt", attempt))
+	}
+	assertEquals(t, terr.Fingerprint(newErr(1)), terr.Fingerprint(newErr(2)))
+}
@@ -0,0 +1,43 @@
+package terr
+
+import (
+	"hash/fnv"
+	"regexp"
+	"strconv"
+)
+
+// Patterns matching the variable parts of messages, replaced in this order by
+// NormalizeMessage.
+var (
+	quotedPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|` + "`[^`]*`")
+	uuidPattern   = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`)
+	numberPattern = regexp.MustCompile(`\b(?:0[xX][0-9a-fA-F]+|\d+(?:[.:]\d+)*)`)
+)
+
+// NormalizeMessage replaces the variable parts of msg with placeholders:
+// quoted values become <str>, UUIDs become <uuid> and numbers (including
+// hexadecimal numbers, IP addresses and times) become <num>. Numbers that are
+// part of words, like in "utf8", are kept. This way, messages like "timeout
+// after 31ms" and "timeout after 87ms" are both normalized to "timeout after
+// <num>ms", and can be grouped together.
+func NormalizeMessage(msg string) string {
+	msg = quotedPattern.ReplaceAllLiteralString(msg, "<str>")
+	msg = uuidPattern.ReplaceAllLiteralString(msg, "<uuid>")
+	return numberPattern.ReplaceAllLiteralString(msg, "<num>")
+}
+
+// GroupingKey returns a hexadecimal hash identifying the error tracing tree
+// for err by its structure and normalized messages. Unlike Fingerprint, it
+// tells apart errors created at the same locations with different messages,
+// such as errors wrapping different non-traced errors, while still grouping
+// errors whose messages only differ in their variable parts, as defined by
+// NormalizeMessage. Returns an empty string if err has no traced error.
+func GroupingKey(err error) string {
+	et := TraceTree(err)
+	if et == nil {
+		return ""
+	}
+	h := fnv.New64a()
+	hashTree(h, et, true)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
@@ -0,0 +1,46 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"timeout after 31ms", "timeout after <num>ms"},
+		{"timeout after 87ms", "timeout after <num>ms"},
+		{`user "alice" not found`, "user <str> not found"},
+		{`key 'a b' and ` + "`c`", "key <str> and <str>"},
+		{"order 123e4567-e89b-12d3-a456-426614174000 failed", "order <uuid> failed"},
+		{"dial 10.0.0.1:5432 at 0x1f", "dial <num> at <num>"},
+		{"took 1.5s, attempt 3 of 10", "took <num>s, attempt <num> of <num>"},
+		{"invalid utf8 in v2 header", "invalid utf8 in v2 header"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		assertEquals(t, terr.NormalizeMessage(test.msg), test.want)
+	}
+}
+
+func TestGroupingKey(t *testing.T) {
+	newErr := func(err error) error {
+		return terr.Newf("query: %w", err)
+	}
+	slow := newErr(errors.New("timeout after 31ms"))
+	slower := newErr(errors.New("timeout after 87ms"))
+	refused := newErr(errors.New("connection refused"))
+
+	assertEquals(t, terr.GroupingKey(slow), terr.GroupingKey(slower))
+	assertEquals(t, terr.GroupingKey(slow) != terr.GroupingKey(refused), true)
+	assertEquals(t, terr.Fingerprint(slow), terr.Fingerprint(refused))
+	assertEquals(t, terr.GroupingKey(fmt.Errorf("wrapped: %w", slow)), terr.GroupingKey(slow))
+	assertEquals(t, terr.GroupingKey(errors.New("fail")), "")
+}
@@ -0,0 +1,75 @@
+package terr
+
+import "strings"
+
+// WithOp sets the operation being performed when the traced error occurred,
+// such as "user.Get" or "storage.Write". Operations describe what the program
+// was doing at each level of the error tracing tree, and can be retrieved
+// with Ops.
+func WithOp(op string) TraceOption {
+	return func(e *tracedError) {
+		e.op = op
+		e.autoOp = false
+	}
+}
+
+// WithAutoOp sets the operation of the traced error to the name of the
+// function where it was created, qualified by its package name, such as
+// "user.(*Store).Get". It can be enabled for all traced errors with the
+// AutoOp field of Config.
+func WithAutoOp() TraceOption {
+	return func(e *tracedError) {
+		e.op = ""
+		e.autoOp = true
+	}
+}
+
+// opOf returns the operation of et, or an empty string if it has none.
+func opOf(et ErrorTracer) string {
+	te, ok := et.(*tracedError)
+	if !ok {
+		return ""
+	}
+	if te.autoOp {
+		return funcOp(te.resolveLocation().function)
+	}
+	return te.op
+}
+
+// funcOp returns the name of function without its package path, so
+// "github.com/user/app/user.(*Store).Get" becomes "user.(*Store).Get".
+func funcOp(function string) string {
+	if i := strings.LastIndexByte(function, '/'); i >= 0 {
+		return function[i+1:]
+	}
+	return function
+}
+
+// Op returns the operation of et, as set with WithOp or WithAutoOp, or an
+// empty string if it has none.
+func Op(et ErrorTracer) string {
+	return opOf(et)
+}
+
+// Ops returns the operations of all traced errors in the error tracing tree
+// for err, from the outermost to the innermost in depth-first order, like
+// ["api.CreateUser", "user.(*Store).Insert", "db.Exec"]. Traced errors
+// without operations are skipped. The error tracing tree is searched for in
+// the Go error tree of err, so it is found even if err wraps a traced error.
+func Ops(err error) []string {
+	var ops []string
+	walkErrors(err, func(err error) bool {
+		te, ok := err.(*tracedError)
+		if !ok {
+			return true
+		}
+		walkTree(te, func(et ErrorTracer) bool {
+			if op := opOf(et); op != "" {
+				ops = append(ops, op)
+			}
+			return true
+		})
+		return false
+	})
+	return ops
+}
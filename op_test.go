@@ -0,0 +1,49 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func insertUser() error {
+	return terr.Trace(errors.New("duplicate key"), terr.WithAutoOp())
+}
+
+func TestOps(t *testing.T) {
+	err := terr.Trace(insertUser(), terr.WithOp("api.CreateUser"))
+	wrapped := fmt.Errorf("request failed: %w", err)
+
+	assertEquals(t, strings.Join(terr.Ops(wrapped), ","), "api.CreateUser,terr_test.insertUser")
+	assertEquals(t, terr.Op(terr.TraceTree(err)), "api.CreateUser")
+	assertEquals(t, terr.Ops(errors.New("fail")) == nil, true)
+	assertEquals(t, terr.Op(terr.TraceTree(terr.Newf("fail"))), "")
+
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, strings.Contains(string(b), `"op":"api.CreateUser"`), true)
+	assertEquals(t, strings.Contains(string(b), `"op":"terr_test.insertUser"`), true)
+}
+
+func TestWithOpOverridesAutoOp(t *testing.T) {
+	err := terr.Trace(errors.New("fail"), terr.WithAutoOp(), terr.WithOp("op"))
+	assertEquals(t, terr.Op(terr.TraceTree(err)), "op")
+	err = terr.Trace(errors.New("fail"), terr.WithOp("op"), terr.WithAutoOp())
+	assertEquals(t, terr.Op(terr.TraceTree(err)), "terr_test.TestWithOpOverridesAutoOp")
+}
+
+func TestAutoOpDefault(t *testing.T) {
+	terr.SetDefaults(terr.Config{AutoOp: true})
+	defer terr.SetDefaults(terr.Config{})
+
+	err := terr.Newf("fail")
+	assertEquals(t, strings.Join(terr.Ops(err), ","), "terr_test.TestAutoOpDefault")
+	err = terr.Trace(err, terr.WithOp("explicit"))
+	assertEquals(t, strings.Join(terr.Ops(err), ","), "explicit,terr_test.TestAutoOpDefault")
+}
@@ -0,0 +1,68 @@
+package terr
+
+import "sort"
+
+// ChildOrder defines the order in which the children of traced errors are
+// printed or serialized.
+type ChildOrder int
+
+const (
+	// OrderAdded keeps children in the order in which they were added, which
+	// for Newf is the order of its arguments. It is the default.
+	OrderAdded ChildOrder = iota
+	// OrderLocation sorts children by file and line.
+	OrderLocation
+	// OrderTime sorts children by the time set with WithTime, placing the
+	// ones without a time last.
+	OrderTime
+	// OrderMessage sorts children by message.
+	OrderMessage
+)
+
+// SortChildren sorts the children of all traced errors in the given order
+// when exporting, so aggregated trees produce stable, comparable output
+// across runs. Children that are equal according to order keep the order in
+// which they were added.
+func SortChildren(order ChildOrder) ExportOption {
+	return func(cfg *exportConfig) {
+		cfg.order = order
+	}
+}
+
+// sortedChildren returns children sorted in the given order. children itself
+// is returned if they are already sorted.
+func sortedChildren(children []ErrorTracer, order ChildOrder) []ErrorTracer {
+	var less func(a, b ErrorTracer) bool
+	switch order {
+	case OrderLocation:
+		less = func(a, b ErrorTracer) bool {
+			aFile, aLine := a.Location()
+			bFile, bLine := b.Location()
+			return aFile < bFile || aFile == bFile && aLine < bLine
+		}
+	case OrderTime:
+		less = func(a, b ErrorTracer) bool {
+			aTime, aOK := Time(a)
+			bTime, bOK := Time(b)
+			return aOK && (!bOK || aTime.Before(bTime))
+		}
+	case OrderMessage:
+		less = func(a, b ErrorTracer) bool {
+			return a.Error() < b.Error()
+		}
+	default:
+		return children
+	}
+	sorted := true
+	for i := 1; i < len(children) && sorted; i++ {
+		sorted = !less(children[i], children[i-1])
+	}
+	if sorted {
+		return children
+	}
+	children = append([]ErrorTracer(nil), children...)
+	sort.SliceStable(children, func(i, j int) bool {
+		return less(children[i], children[j])
+	})
+	return children
+}
@@ -0,0 +1,73 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestChildOrder(t *testing.T) {
+	file, line := getLocation(0)
+	b := terr.Newf("b")
+	a := terr.Newf("a")
+	c := terr.Newf("c")
+	err := terr.Newf("batch: %w, %w, %w", c, a, b)
+
+	// Children keep the order of the arguments by default.
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("batch: c, a, b @ %s:%d", file, line+4),
+		fmt.Sprintf("\tc @ %s:%d", file, line+3),
+		fmt.Sprintf("\ta @ %s:%d", file, line+2),
+		fmt.Sprintf("\tb @ %s:%d", file, line+1),
+	}, "\n"))
+
+	terr.SetTreeStyle(terr.TreeStyle{Order: terr.OrderMessage})
+	defer terr.SetTreeStyle(terr.TreeStyle{})
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("batch: c, a, b @ %s:%d", file, line+4),
+		fmt.Sprintf("\ta @ %s:%d", file, line+2),
+		fmt.Sprintf("\tb @ %s:%d", file, line+1),
+		fmt.Sprintf("\tc @ %s:%d", file, line+3),
+	}, "\n"))
+
+	terr.SetTreeStyle(terr.TreeStyle{Order: terr.OrderLocation})
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("batch: c, a, b @ %s:%d", file, line+4),
+		fmt.Sprintf("\tb @ %s:%d", file, line+1),
+		fmt.Sprintf("\ta @ %s:%d", file, line+2),
+		fmt.Sprintf("\tc @ %s:%d", file, line+3),
+	}, "\n"))
+}
+
+func TestSortChildren(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	err := terr.NewBuilder("batch").Location("batch.go", 1).Child(
+		terr.NewBuilder("c").Location("c.go", 1).Time(ts.Add(time.Minute)),
+		terr.NewBuilder("a").Location("a.go", 1),
+		terr.NewBuilder("b").Location("b.go", 1).Time(ts),
+	).Build()
+
+	messages := func(data []byte) string {
+		var tree struct {
+			Children []struct {
+				Message string `json:"message"`
+			} `json:"children"`
+		}
+		assertErrorIsNil(t, json.Unmarshal(data, &tree))
+		var msgs []string
+		for _, child := range tree.Children {
+			msgs = append(msgs, child.Message)
+		}
+		return strings.Join(msgs, ",")
+	}
+	assertEquals(t, messages(terr.Export(err)), "c,a,b")
+	assertEquals(t, messages(terr.Export(err, terr.SortChildren(terr.OrderLocation))), "a,b,c")
+	assertEquals(t, messages(terr.Export(err, terr.SortChildren(terr.OrderTime))), "b,c,a")
+	assertEquals(t, messages(terr.Export(err, terr.SortChildren(terr.OrderMessage))), "a,b,c")
+}
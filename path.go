@@ -0,0 +1,43 @@
+package terr
+
+// PathTo returns the chain of nodes from root down to node in the error
+// tracing tree rooted in root, including both of them, so tooling that finds
+// an interesting node (e.g., a leaf with a given fingerprint) can show the
+// context in which it occurred. Nodes are compared by identity, and if node
+// appears more than once in the tree, the path to its first occurrence in
+// depth-first order is returned. Returns nil if node is not in the tree.
+// Nodes are kept in an explicit stack, so arbitrarily deep trees cannot
+// overflow the goroutine stack.
+func PathTo(root, node ErrorTracer) []ErrorTracer {
+	if root == nil || node == nil {
+		return nil
+	}
+	// pathNode is a node along with the index of its parent in visited.
+	type pathNode struct {
+		et     ErrorTracer
+		parent int
+	}
+	var visited []pathNode
+	stack := []pathNode{{root, -1}}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if sameError(n.et, node) {
+			var path []ErrorTracer
+			for ; n.parent >= 0; n = visited[n.parent] {
+				path = append(path, n.et)
+			}
+			path = append(path, n.et)
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path
+		}
+		visited = append(visited, n)
+		children := childrenOf(n.et)
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, pathNode{children[i], len(visited) - 1})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestPathTo(t *testing.T) {
+	leaf := terr.Newf("leaf")
+	other := terr.Newf("other")
+	middle := terr.Newf("middle: %w", leaf)
+	root := terr.TraceTree(terr.Newf("root: %w, %w", other, middle))
+
+	messages := func(path []terr.ErrorTracer) []string {
+		var msgs []string
+		for _, et := range path {
+			msgs = append(msgs, et.Error())
+		}
+		return msgs
+	}
+	assertEquals(t, fmt.Sprint(messages(terr.PathTo(root, terr.TraceTree(leaf)))), "[root: other, middle: leaf middle: leaf leaf]")
+	assertEquals(t, fmt.Sprint(messages(terr.PathTo(root, root))), "[root: other, middle: leaf]")
+	assertEquals(t, terr.PathTo(root, terr.TraceTree(terr.Newf("leaf"))) == nil, true)
+	assertEquals(t, terr.PathTo(nil, root) == nil, true)
+}
@@ -0,0 +1,369 @@
+package terr
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// appendTree appends a multi-line representation of the error tracing tree
+// rooted in et to dst, laid out according to the configured TreeStyle,
+// returning the extended buffer. Structurally identical children are printed
+// only once, annotated with the number of times they were repeated.
+func appendTree(dst []byte, et ErrorTracer) []byte {
+	style := getTreeStyle()
+	return appendNode(dst, et, style, []byte(style.rootIndent()), 1, false, 0)
+}
+
+// appendNode appends the representation of the error tracing tree rooted in
+// et, which was repeated count times, to dst. The line of et itself must
+// already be indented, and indent is the prefix for the lines below it. If
+// byRootCause is true, children are grouped by their root causes instead of
+// by their structure. Children classified as internal by the filter set with
+// SetInternalFrames are replaced by their own children, unless style is
+// verbose, and children are sorted in the order set in style. If maxDepth is
+// positive, only that many levels of the tree are appended, and the children
+// below them are counted as omitted. Nodes are kept in an explicit stack, so
+// arbitrarily deep trees cannot overflow the goroutine stack.
+func appendNode(dst []byte, et ErrorTracer, style *TreeStyle, indent []byte, count int, byRootCause bool, maxDepth int) []byte {
+	// nodeFrame is a node whose children are being appended.
+	type nodeFrame struct {
+		// groups[start:end] are the groups of children of the node, and next
+		// is the index of the next group to be appended.
+		start, next, end int
+		omitted          int
+		// indent is the length of the indentation of the node's children.
+		indent int
+	}
+	var frameBuf [8]nodeFrame
+	var groupBuf [16]childGroup
+	frames, groups := frameBuf[:0], groupBuf[:0]
+	internal := internalFilter(style)
+	for {
+		start := len(groups)
+		children, omitted := printedChildren(et, style, internal, maxDepth > 0 && len(frames)+1 >= maxDepth)
+		groups = append(groups[:start], groupPrinted(groups[start:], children, byRootCause)...)
+		dst = appendNodeLine(dst, et, style, indent, count, byRootCause, len(groups) > start || omitted > 0)
+		frames = append(frames, nodeFrame{start, start, len(groups), omitted, len(indent)})
+
+		// Find the next group of children to append, finishing the nodes
+		// that have none left.
+		for len(frames) > 0 {
+			f := &frames[len(frames)-1]
+			indent = indent[:f.indent]
+			if f.next < f.end {
+				break
+			}
+			if f.omitted > 0 {
+				dst = append(dst, '\n')
+				dst = append(dst, indent...)
+				if style.Connectors {
+					dst = append(dst, connectorLast...)
+				}
+				dst = append(dst, "("...)
+				dst = strconv.AppendInt(dst, int64(f.omitted), 10)
+				dst = append(dst, " more children omitted)"...)
+			}
+			groups = groups[:f.start]
+			frames = frames[:len(frames)-1]
+		}
+		if len(frames) == 0 {
+			return dst
+		}
+
+		f := &frames[len(frames)-1]
+		group := groups[f.next]
+		f.next++
+		dst = append(dst, '\n')
+		dst = append(dst, indent...)
+		// The indentation of children is appended to indent in place, since
+		// previous siblings no longer need the bytes past its end.
+		switch {
+		case !style.Connectors:
+			indent = append(indent, style.Indent...)
+		case f.next == f.end && f.omitted == 0:
+			dst = append(dst, connectorLast...)
+			indent = append(indent, connectorSpace...)
+		default:
+			dst = append(dst, connectorBranch...)
+			indent = append(indent, connectorPipe...)
+		}
+		et, count = group.ErrorTracer, group.count
+	}
+}
+
+// printedChildren returns the children of et to be printed with style, using
+// internal as the internal frames filter, along with the number of children
+// omitted from them. If truncated is true, all children are omitted.
+func printedChildren(et ErrorTracer, style *TreeStyle, internal *func(string) bool, truncated bool) ([]ErrorTracer, int) {
+	children := childrenOf(et)
+	if internal != nil {
+		children = visibleChildren(children, *internal)
+	}
+	omitted := OmittedChildren(et)
+	if truncated {
+		return nil, omitted + len(children)
+	}
+	return sortedChildren(children, style.Order), omitted
+}
+
+// groupPrinted appends the groups of children to dst, grouping them by their
+// root causes if byRootCause is true, or by their structure otherwise.
+func groupPrinted(dst []childGroup, children []ErrorTracer, byRootCause bool) []childGroup {
+	if byRootCause {
+		return groupByRootCause(dst, children)
+	}
+	return groupChildren(dst, children)
+}
+
+// appendNodeHead appends the message of et, which was repeated count times,
+// to dst, followed by its location (if it has one), function (if style asks
+// for it) and metadata.
+func appendNodeHead(dst []byte, et ErrorTracer, style *TreeStyle, count int, byRootCause bool) []byte {
+	dst = append(dst, et.Error()...)
+	if file, line := et.Location(); file != "" {
+		dst = append(dst, style.Separator...)
+		dst = append(dst, file...)
+		dst = append(dst, ':')
+		dst = strconv.AppendInt(dst, int64(line), 10)
+	}
+	if style.Functions {
+		if function := funcOf(et); function != "" {
+			dst = append(dst, " in "...)
+			dst = append(dst, function...)
+		}
+	}
+	dst = appendMetadataText(dst, et)
+	if count > 1 {
+		dst = append(dst, " (x"...)
+		dst = strconv.AppendInt(dst, int64(count), 10)
+		if byRootCause {
+			dst = append(dst, ", same root cause"...)
+		}
+		dst = append(dst, ')')
+	}
+	return dst
+}
+
+// appendNodeLine appends the line of et, which was repeated count times, to
+// dst, followed by its hints and URL, indented by indent. hasChildren is
+// whether et has children or omitted children to be appended after it.
+func appendNodeLine(dst []byte, et ErrorTracer, style *TreeStyle, indent []byte, count int, byRootCause, hasChildren bool) []byte {
+	dst = appendNodeHead(dst, et, style, count, byRootCause)
+
+	// detail is the prefix for the lines with details about et, which must
+	// keep connectors to the children below them.
+	detail := ""
+	if style.Connectors {
+		detail = connectorSpace
+		if hasChildren {
+			detail = connectorPipe
+		}
+	}
+	for _, hint := range hintsOf(et) {
+		dst = append(dst, '\n')
+		dst = append(dst, indent...)
+		dst = append(dst, detail...)
+		dst = append(dst, "(hint: "...)
+		dst = append(dst, hint...)
+		dst = append(dst, ')')
+	}
+	if te, ok := et.(*tracedError); ok {
+		if url := te.getURL(); url != "" {
+			dst = append(dst, '\n')
+			dst = append(dst, indent...)
+			dst = append(dst, detail...)
+			dst = append(dst, "(see: "...)
+			dst = append(dst, url...)
+			dst = append(dst, ')')
+		}
+	}
+	return dst
+}
+
+// appendCompactTree appends a single-line representation of the error tracing
+// tree rooted in et to dst, as printed by the %-@ verb, returning the extended
+// buffer. A node with a single child is followed by " -> " and that child,
+// and a node with multiple children by " -> " and its children between
+// brackets, separated by "; ". Hints and URLs are left out, and newlines in
+// messages are replaced by spaces. byRootCause and maxDepth work as in
+// appendNode.
+func appendCompactTree(dst []byte, et ErrorTracer, style *TreeStyle, byRootCause bool, maxDepth int) []byte {
+	// compactFrame is a node whose children are being appended.
+	type compactFrame struct {
+		start, next, end int
+		omitted          int
+		bracketed        bool
+	}
+	var frames []compactFrame
+	var groups []childGroup
+	internal := internalFilter(style)
+	count := 1
+	for {
+		start := len(groups)
+		children, omitted := printedChildren(et, style, internal, maxDepth > 0 && len(frames)+1 >= maxDepth)
+		groups = append(groups[:start], groupPrinted(groups[start:], children, byRootCause)...)
+		head := len(dst)
+		dst = appendNodeHead(dst, et, style, count, byRootCause)
+		for i := head; i < len(dst); i++ {
+			if dst[i] == '\n' {
+				dst[i] = ' '
+			}
+		}
+		items := len(groups) - start
+		if omitted > 0 {
+			items++
+		}
+		if items > 0 {
+			dst = append(dst, " -> "...)
+		}
+		if items > 1 {
+			dst = append(dst, '[')
+		}
+		frames = append(frames, compactFrame{start, start, len(groups), omitted, items > 1})
+
+		// Find the next group of children to append, finishing the nodes
+		// that have none left.
+		for len(frames) > 0 {
+			f := &frames[len(frames)-1]
+			if f.next < f.end {
+				break
+			}
+			if f.omitted > 0 {
+				if f.end > f.start {
+					dst = append(dst, "; "...)
+				}
+				dst = append(dst, '(')
+				dst = strconv.AppendInt(dst, int64(f.omitted), 10)
+				dst = append(dst, " more children omitted)"...)
+			}
+			if f.bracketed {
+				dst = append(dst, ']')
+			}
+			groups = groups[:f.start]
+			frames = frames[:len(frames)-1]
+		}
+		if len(frames) == 0 {
+			return dst
+		}
+
+		f := &frames[len(frames)-1]
+		if f.next > f.start {
+			dst = append(dst, "; "...)
+		}
+		group := groups[f.next]
+		f.next++
+		et, count = group.ErrorTracer, group.count
+	}
+}
+
+// maxPooledTreeBuffer is the capacity above which buffers used for rendering
+// error tracing trees are not returned to treeBuffers, so a single huge tree
+// does not pin memory forever.
+const maxPooledTreeBuffer = 64 << 10
+
+// treeBuffers holds buffers for rendering error tracing trees.
+var treeBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, 1024)
+		return &buf
+	},
+}
+
+// renderTree returns the representation of the error tracing tree rooted in
+// et in the given style. It is rendered into a pooled buffer, so only the
+// returned string needs to be allocated.
+func renderTree(et ErrorTracer, style *TreeStyle) string {
+	buf := treeBuffers.Get().(*[]byte)
+	*buf = appendNode((*buf)[:0], et, style, []byte(style.rootIndent()), 1, false, 0)
+	repr := string(*buf)
+	if cap(*buf) <= maxPooledTreeBuffer {
+		treeBuffers.Put(buf)
+	}
+	return repr
+}
+
+// AppendTree appends the error tracing tree for err to dst in the same
+// representation used by the %@ verb, returning the extended buffer. If err
+// is not a traced error, it is appended as fmt.Sprint would format it.
+// No intermediate allocations take place when formatting traced errors, so
+// this function is suitable for use with buffer-reusing loggers.
+func AppendTree(dst []byte, err error) []byte {
+	if te, ok := err.(*tracedError); ok && te != nil {
+		te.report()
+		return append(dst, te.tree()...)
+	}
+	if err == nil {
+		return append(dst, "<nil>"...)
+	}
+	return append(dst, err.Error()...)
+}
+
+// Sprint returns the error tracing tree for err in the same representation
+// used by the %@ verb. If err is not a traced error, it is formatted as
+// fmt.Sprint would.
+func Sprint(err error) string {
+	if te, ok := err.(*tracedError); ok && te != nil {
+		te.report()
+		return te.tree()
+	}
+	return fmt.Sprint(err)
+}
+
+// FormatAny works like Sprint, but it also finds traced errors wrapped by
+// non-traced errors, as TraceTree does, so call sites do not need to know
+// whether err is traced. In that case, the message of err is followed by the
+// error tracing tree of the nearest traced error, indented one level. If err
+// has no traced error, it is formatted as fmt.Sprint would.
+func FormatAny(err error) string {
+	if te, ok := err.(*tracedError); ok && te != nil {
+		return Sprint(err)
+	}
+	te := findTraced(err)
+	if te == nil {
+		return fmt.Sprint(err)
+	}
+	te.report()
+	style := getTreeStyle()
+	buf := append([]byte(err.Error()), '\n')
+	indent := []byte(style.Indent + style.Indent)
+	if style.Connectors {
+		buf = append(buf, connectorLast...)
+		indent = []byte(connectorSpace)
+	} else {
+		buf = append(buf, style.Indent...)
+	}
+	return string(appendNode(buf, te, style, indent, 1, false, 0))
+}
+
+// Sprintln works exactly like Sprint, but a newline is appended to the
+// returned string.
+func Sprintln(err error) string {
+	return Sprint(err) + "\n"
+}
+
+// Fprint writes the error tracing tree for err to w, as returned by Sprint.
+// It returns the number of bytes written and any write error encountered.
+func Fprint(w io.Writer, err error) (int, error) {
+	return io.WriteString(w, Sprint(err))
+}
+
+// Fprintln works exactly like Fprint, but a newline is appended to the
+// output.
+func Fprintln(w io.Writer, err error) (int, error) {
+	return io.WriteString(w, Sprintln(err))
+}
+
+// Print writes the error tracing tree for err to standard output, as returned
+// by Sprint. It returns the number of bytes written and any write error
+// encountered.
+func Print(err error) (int, error) {
+	return Fprint(os.Stdout, err)
+}
+
+// Println works exactly like Print, but a newline is appended to the output.
+func Println(err error) (int, error) {
+	return Fprintln(os.Stdout, err)
+}
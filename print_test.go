@@ -0,0 +1,225 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestSprint(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Newf("fail")
+	tracedErr := terr.Trace(err)
+
+	want := strings.Join([]string{
+		fmt.Sprintf("fail @ %s:%d", file, line+2),
+		fmt.Sprintf("\tfail @ %s:%d", file, line+1),
+	}, "\n")
+	assertEquals(t, terr.Sprint(tracedErr), want)
+	assertEquals(t, terr.Sprintln(tracedErr), want+"\n")
+	assertEquals(t, terr.Sprint(tracedErr), fmt.Sprintf("%@", tracedErr))
+}
+
+func TestSprintNonTraced(t *testing.T) {
+	assertEquals(t, terr.Sprint(errors.New("fail")), "fail")
+	assertEquals(t, terr.Sprintln(errors.New("fail")), "fail\n")
+	assertEquals(t, terr.Sprint(nil), "<nil>")
+}
+
+func TestAppendTree(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Newf("fail")
+	tracedErr := terr.Trace(err)
+
+	buf := terr.AppendTree([]byte("prefix: "), tracedErr)
+	assertEquals(t, string(buf), strings.Join([]string{
+		fmt.Sprintf("prefix: fail @ %s:%d", file, line+2),
+		fmt.Sprintf("\tfail @ %s:%d", file, line+1),
+	}, "\n"))
+	assertEquals(t, string(terr.AppendTree(nil, errors.New("fail"))), "fail")
+	assertEquals(t, string(terr.AppendTree(nil, nil)), "<nil>")
+}
+
+func TestAppendTreeAllocs(t *testing.T) {
+	err := terr.Newf("wrapped: %w", terr.Trace(terr.Newf("fail")))
+	buf := make([]byte, 0, 1024)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = terr.AppendTree(buf[:0], err)
+	})
+	assertEquals(t, allocs, 0.0)
+}
+
+func TestTreeCached(t *testing.T) {
+	err := terr.Newf("wrapped: %w", terr.Trace(terr.Newf("fail")))
+	want := fmt.Sprintf("%@", err)
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = terr.Sprint(err)
+	})
+	assertEquals(t, terr.Sprint(err), want)
+	assertEquals(t, allocs, 0.0)
+}
+
+func TestFprint(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Trace(terr.Newf("fail"), terr.WithMetadata("key", "a value"))
+
+	var buf bytes.Buffer
+	n, writeErr := terr.Fprint(&buf, err)
+	assertErrorIsNil(t, writeErr)
+	want := strings.Join([]string{
+		fmt.Sprintf(`fail @ %s:%d [key="a value"]`, file, line+1),
+		fmt.Sprintf("\tfail @ %s:%d", file, line+1),
+	}, "\n")
+	assertEquals(t, buf.String(), want)
+	assertEquals(t, n, len(want))
+
+	buf.Reset()
+	_, writeErr = terr.Fprintln(&buf, errors.New("fail"))
+	assertErrorIsNil(t, writeErr)
+	assertEquals(t, buf.String(), "fail\n")
+}
+
+func BenchmarkSprintLargeTree(b *testing.B) {
+	children := make([]terr.ErrorTracer, 100)
+	for i := range children {
+		child := terr.Trace(terr.Newf("child"), terr.WithCode(fmt.Sprint(i)))
+		children[i] = terr.TraceTree(child)
+	}
+	base := errors.New("fail")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = terr.Sprint(terr.Trace(base, terr.WithChildren(children...)))
+	}
+}
+
+func TestSetTreeVerb(t *testing.T) {
+	terr.SetTreeVerb('e')
+	defer terr.SetTreeVerb(0)
+
+	file, line := getLocation(0)
+	err := terr.Trace(errors.New("fail"))
+
+	assertEquals(t, fmt.Sprintf("%e", err), fmt.Sprintf("fail @ %s:%d", file, line+1))
+	assertEquals(t, fmt.Sprintf("%@", err), "&{%!@(string=fail)}")
+
+	terr.SetTreeVerb(0)
+	assertEquals(t, fmt.Sprintf("%@", err), fmt.Sprintf("fail @ %s:%d", file, line+1))
+}
+
+func TestFormatAny(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Trace(errors.New("fail"))
+	wrapped := fmt.Errorf("third-party: %w", err)
+
+	assertEquals(t, terr.FormatAny(err), fmt.Sprintf("fail @ %s:%d", file, line+1))
+	assertEquals(t, terr.FormatAny(wrapped), strings.Join([]string{
+		"third-party: fail",
+		fmt.Sprintf("\tfail @ %s:%d", file, line+1),
+	}, "\n"))
+	assertEquals(t, terr.FormatAny(errors.New("plain")), "plain")
+	assertEquals(t, terr.FormatAny(nil), "<nil>")
+}
+
+func TestSetTreeStyle(t *testing.T) {
+	defer terr.SetTreeStyle(terr.TreeStyle{})
+
+	file, line := getLocation(0)
+	leaf := terr.Trace(errors.New("leaf"), terr.WithHint("retry later"))
+	err := terr.Newf("root: %w and %w", terr.Newf("first: %w", leaf), errors.New("second"))
+
+	terr.SetTreeStyle(terr.TreeStyle{Indent: "  ", Separator: " at "})
+	assertEquals(t, terr.Sprint(err), strings.Join([]string{
+		fmt.Sprintf("root: first: leaf and second at %s:%d", file, line+2),
+		fmt.Sprintf("  first: leaf at %s:%d", file, line+2),
+		fmt.Sprintf("    leaf at %s:%d", file, line+1),
+		"      (hint: retry later)",
+	}, "\n"))
+
+	terr.SetTreeStyle(terr.TreeStyle{Connectors: true})
+	err = terr.Newf("root: %w %w", leaf, terr.Newf("other: %w", leaf))
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("root: leaf other: leaf @ %s:%d", file, line+13),
+		fmt.Sprintf("├── leaf @ %s:%d", file, line+1),
+		"│       (hint: retry later)",
+		fmt.Sprintf("└── other: leaf @ %s:%d", file, line+13),
+		fmt.Sprintf("    └── leaf @ %s:%d", file, line+1),
+		"            (hint: retry later)",
+	}, "\n"))
+
+	terr.SetTreeStyle(terr.TreeStyle{})
+	assertEquals(t, terr.Sprint(leaf), strings.Join([]string{
+		fmt.Sprintf("leaf @ %s:%d", file, line+1),
+		"\t(hint: retry later)",
+	}, "\n"))
+}
+
+func TestGoString(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Newf("fail: %w %w", terr.Newf("a"), terr.Newf("b"))
+
+	want := fmt.Sprintf(`&terr.tracedError{Message: "fail: a b", Location: "%s:%d", Children: 2}`, file, line+1)
+	assertEquals(t, fmt.Sprintf("%#v", err), want)
+	assertEquals(t, terr.TraceTree(err).(fmt.GoStringer).GoString(), want)
+	assertEquals(t, fmt.Sprintf("%v", err), "fail: a b")
+}
+
+func TestFormatPlusV(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Newf("wrapped: %w", terr.Newf("fail"))
+
+	assertEquals(t, fmt.Sprintf("%+v", err), strings.Join([]string{
+		fmt.Sprintf("wrapped: fail @ %s:%d", file, line+1),
+		fmt.Sprintf("\tfail @ %s:%d", file, line+1),
+	}, "\n"))
+	assertEquals(t, fmt.Sprintf("%+v", err), fmt.Sprintf("%@", err))
+	assertEquals(t, fmt.Sprintf("%v", err), "wrapped: fail")
+	assertEquals(t, fmt.Sprintf("%s", err), "wrapped: fail")
+}
+
+func TestFormatFlags(t *testing.T) {
+	file, line := getLocation(0)
+	leaf := terr.Newf("leaf")
+	mid := terr.Newf("mid: %w", leaf)
+	other := terr.Newf("other")
+	err := terr.Newf("root: %w, %w", mid, other)
+
+	assertEquals(t, fmt.Sprintf("%-@", err), fmt.Sprintf(
+		"root: mid: leaf, other @ %[1]s:%[2]d -> [mid: leaf @ %[1]s:%[3]d -> leaf @ %[1]s:%[4]d; other @ %[1]s:%[5]d]",
+		file, line+4, line+2, line+1, line+3))
+	assertEquals(t, fmt.Sprintf("%-@", leaf), fmt.Sprintf("leaf @ %s:%d", file, line+1))
+
+	assertEquals(t, fmt.Sprintf("%.2@", err), strings.Join([]string{
+		fmt.Sprintf("root: mid: leaf, other @ %s:%d", file, line+4),
+		fmt.Sprintf("\tmid: leaf @ %s:%d", file, line+2),
+		"\t\t(1 more children omitted)",
+		fmt.Sprintf("\tother @ %s:%d", file, line+3),
+	}, "\n"))
+	assertEquals(t, fmt.Sprintf("%.1@", err), strings.Join([]string{
+		fmt.Sprintf("root: mid: leaf, other @ %s:%d", file, line+4),
+		"\t(2 more children omitted)",
+	}, "\n"))
+	assertEquals(t, fmt.Sprintf("%-.1@", err), fmt.Sprintf(
+		"root: mid: leaf, other @ %s:%d -> (2 more children omitted)", file, line+4))
+
+	fn := " in github.com/alnvdl/terr_test.TestFormatFlags"
+	assertEquals(t, fmt.Sprintf("%+.2@", mid), strings.Join([]string{
+		fmt.Sprintf("mid: leaf @ %s:%d%s", file, line+2, fn),
+		fmt.Sprintf("\tleaf @ %s:%d%s", file, line+1, fn),
+	}, "\n"))
+	assertEquals(t, fmt.Sprintf("%+-@", mid), fmt.Sprintf(
+		"mid: leaf @ %[1]s:%[2]d%[4]s -> leaf @ %[1]s:%[3]d%[4]s", file, line+2, line+1, fn))
+}
+
+func TestFormatCompactMultiline(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Newf("first\nsecond")
+
+	assertEquals(t, fmt.Sprintf("%-@", err), fmt.Sprintf("first second @ %s:%d", file, line+1))
+}
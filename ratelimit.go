@@ -0,0 +1,66 @@
+package terr
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rateLimit is the maximum number of traced errors per second per call site.
+// Zero means no limit.
+var rateLimit atomic.Int64
+
+var limiter = struct {
+	sync.Mutex
+	buckets map[uintptr]*bucket
+}{buckets: make(map[uintptr]*bucket)}
+
+// bucket is a token bucket for a single call site.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// SetRateLimit limits the number of traced errors that can be created per
+// second at each call site to perSecond, protecting latency during error
+// storms. Once a call site exceeds the limit, Newf degrades to fmt.Errorf and
+// Trace and TraceSkip return their error unchanged until the limit allows
+// new traced errors to be created, so representative traces are still kept.
+// A limit of zero or less (the default) disables rate limiting.
+func SetRateLimit(perSecond int) {
+	if perSecond < 0 {
+		perSecond = 0
+	}
+	rateLimit.Store(int64(perSecond))
+	limiter.Lock()
+	limiter.buckets = make(map[uintptr]*bucket)
+	limiter.Unlock()
+}
+
+// allowTrace returns whether a traced error can be created at the call site
+// identified by pc.
+func allowTrace(pc uintptr) bool {
+	limit := float64(rateLimit.Load())
+	if limit == 0 {
+		return true
+	}
+
+	now := time.Now()
+	limiter.Lock()
+	defer limiter.Unlock()
+	b := limiter.buckets[pc]
+	if b == nil {
+		b = &bucket{tokens: limit, last: now}
+		limiter.buckets[pc] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * limit
+	if b.tokens > limit {
+		b.tokens = limit
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,34 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestSetRateLimit(t *testing.T) {
+	terr.SetRateLimit(3)
+	defer terr.SetRateLimit(0)
+
+	var newfTraced, traceTraced int
+	for i := 0; i < 10; i++ {
+		err := terr.Newf("fail %d", i)
+		if terr.TraceTree(err) != nil {
+			newfTraced++
+		}
+		assertEquals(t, err.Error(), terr.Newf("fail %d", i).Error())
+		// Each call site has its own limit.
+		if terr.TraceTree(terr.Trace(err)) != nil {
+			traceTraced++
+		}
+	}
+	assertEquals(t, newfTraced, 3)
+	assertEquals(t, traceTraced, 3)
+
+	terr.SetRateLimit(0)
+	for i := 0; i < 10; i++ {
+		assertEquals(t, terr.TraceTree(terr.Newf("fail")) != nil, true)
+	}
+}
@@ -0,0 +1,224 @@
+package terr
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DumpFormat is a format in which recorded errors can be dumped.
+type DumpFormat int
+
+const (
+	// DumpText dumps each recorded error as its time and fingerprint,
+	// followed by its error tracing tree as printed by the %@ verb.
+	DumpText DumpFormat = iota
+	// DumpJSON dumps each recorded error as a JSON object in a separate line,
+	// with the "time", "fingerprint" and "error" keys. The error is encoded
+	// as done by json.Marshal for traced errors.
+	DumpJSON
+)
+
+// DefaultRecorder is a Recorder keeping up to 100 traced errors, which is
+// dumped by DumpRecent.
+var DefaultRecorder = NewRecorder(100)
+
+// DumpRecent dumps the errors recorded by DefaultRecorder to w in the given
+// format. It can be wired to signal handlers or admin endpoints.
+func DumpRecent(w io.Writer, format DumpFormat) error {
+	return DefaultRecorder.Dump(w, format)
+}
+
+// RecordedError is a traced error kept by a Recorder.
+type RecordedError struct {
+	// Time is when the error was recorded.
+	Time time.Time
+	// Fingerprint is the fingerprint of the error, as returned by Fingerprint.
+	Fingerprint string
+	// Err is the recorded traced error.
+	Err error
+}
+
+// Recorder keeps the last traced errors it recorded in memory, so recent
+// failures of a live process can be inspected without scraping logs. A
+// Recorder is safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []RecordedError
+	// next is the index in entries where the next error will be recorded.
+	next int
+	// full is whether entries has wrapped around at least once.
+	full bool
+	// sites counts the recorded errors by call site, in one bucket per
+	// second, for the last maxHotWindow.
+	sites [maxHotWindow / time.Second]siteBucket
+}
+
+// maxHotWindow is the longest window considered by Recorder.Hot.
+const maxHotWindow = 10 * time.Minute
+
+// siteBucket counts the errors recorded in a given second by call site.
+type siteBucket struct {
+	second int64
+	counts map[CallSite]int
+}
+
+// CallSite identifies a location where errors originated.
+type CallSite struct {
+	File string
+	Line int
+}
+
+// HotCallSite is a call site along with the number of errors that
+// originated in it.
+type HotCallSite struct {
+	CallSite
+	Count int
+}
+
+// NewRecorder returns a Recorder keeping up to size traced errors. A size of
+// zero or less is treated as one.
+func NewRecorder(size int) *Recorder {
+	if size < 1 {
+		size = 1
+	}
+	return &Recorder{entries: make([]RecordedError, size)}
+}
+
+// Record records err if it is a traced error, discarding the oldest recorded
+// error if the Recorder is full. Non-traced errors are ignored.
+func (r *Recorder) Record(err error) {
+	et := TraceTree(err)
+	if et == nil {
+		return
+	}
+	entry := RecordedError{
+		Time:        time.Now(),
+		Fingerprint: Fingerprint(err),
+		Err:         err,
+	}
+	for children := childrenOf(et); len(children) > 0; children = childrenOf(et) {
+		et = children[0]
+	}
+	var site CallSite
+	site.File, site.Line = et.Location()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = entry
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+	second := entry.Time.Unix()
+	bucket := &r.sites[second%int64(len(r.sites))]
+	if bucket.second != second || bucket.counts == nil {
+		bucket.second = second
+		bucket.counts = make(map[CallSite]int)
+	}
+	bucket.counts[site]++
+}
+
+// Hot returns up to n call sites where most of the errors recorded in the
+// last window originated, from the most to the least frequent, so operators
+// can find out what is failing the most in a live process. The call site of
+// an error is the location of its root cause, i.e., the first leaf of its
+// error tracing tree. Errors are counted regardless of the size of the
+// Recorder, with a granularity of one second, and windows longer than ten
+// minutes are treated as ten minutes.
+func (r *Recorder) Hot(n int, window time.Duration) []HotCallSite {
+	if window > maxHotWindow {
+		window = maxHotWindow
+	}
+	now := time.Now().Unix()
+	oldest := now - int64(window/time.Second)
+
+	counts := make(map[CallSite]int)
+	r.mu.Lock()
+	for _, bucket := range r.sites {
+		if bucket.second >= oldest && bucket.second <= now {
+			for site, count := range bucket.counts {
+				counts[site] += count
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	hot := make([]HotCallSite, 0, len(counts))
+	for site, count := range counts {
+		hot = append(hot, HotCallSite{site, count})
+	}
+	sort.Slice(hot, func(i, j int) bool {
+		if hot[i].Count != hot[j].Count {
+			return hot[i].Count > hot[j].Count
+		}
+		if hot[i].File != hot[j].File {
+			return hot[i].File < hot[j].File
+		}
+		return hot[i].Line < hot[j].Line
+	})
+	if n >= 0 && len(hot) > n {
+		hot = hot[:n]
+	}
+	return hot
+}
+
+// Recent returns the recorded errors, from the most to the least recent.
+func (r *Recorder) Recent() []RecordedError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.next
+	if r.full {
+		n = len(r.entries)
+	}
+	recent := make([]RecordedError, 0, n)
+	for i := 1; i <= n; i++ {
+		recent = append(recent, r.entries[(r.next-i+len(r.entries))%len(r.entries)])
+	}
+	return recent
+}
+
+// Errors returns the recorded errors, from the most to the least recent. It
+// can be used as the source of errors for viewer.Handler.
+func (r *Recorder) Errors() []error {
+	recent := r.Recent()
+	errs := make([]error, len(recent))
+	for i := range recent {
+		errs[i] = recent[i].Err
+	}
+	return errs
+}
+
+// Dump writes the recorded errors to w in the given format, from the most to
+// the least recent.
+func (r *Recorder) Dump(w io.Writer, format DumpFormat) error {
+	bw := bufio.NewWriter(w)
+	var buf []byte
+	for _, entry := range r.Recent() {
+		buf = buf[:0]
+		switch format {
+		case DumpJSON:
+			buf = append(buf, `{"time":`...)
+			buf = appendJSONString(buf, entry.Time.Format(time.RFC3339Nano))
+			buf = append(buf, `,"fingerprint":`...)
+			buf = appendJSONString(buf, entry.Fingerprint)
+			buf = append(buf, `,"error":`...)
+			buf = appendJSON(buf, TraceTree(entry.Err), defaultExportConfig(), 1)
+			buf = append(buf, "}\n"...)
+		default:
+			buf = entry.Time.AppendFormat(buf, time.RFC3339Nano)
+			buf = append(buf, " fingerprint "...)
+			buf = append(buf, entry.Fingerprint...)
+			buf = append(buf, '\n')
+			buf = appendTree(buf, TraceTree(entry.Err))
+			buf = append(buf, "\n\n"...)
+		}
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,108 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestRecorder(t *testing.T) {
+	r := terr.NewRecorder(2)
+	assertEquals(t, len(r.Recent()), 0)
+
+	start := time.Now()
+	err1 := terr.Newf("err1")
+	err2 := terr.Newf("err2")
+	err3 := terr.Newf("err3")
+	r.Record(err1)
+	r.Record(errors.New("non-traced"))
+	r.Record(nil)
+	assertEquals(t, len(r.Recent()), 1)
+	assertEquals(t, r.Recent()[0].Err, err1)
+
+	r.Record(err2)
+	r.Record(err3)
+	recent := r.Recent()
+	assertEquals(t, len(recent), 2)
+	assertEquals(t, recent[0].Err, err3)
+	assertEquals(t, recent[0].Fingerprint, terr.Fingerprint(err3))
+	assertEquals(t, recent[0].Time.Before(start), false)
+	assertEquals(t, recent[1].Err, err2)
+
+	errs := r.Errors()
+	assertEquals(t, len(errs), 2)
+	assertEquals(t, errs[0], err3)
+	assertEquals(t, errs[1], err2)
+}
+
+func TestRecorderDump(t *testing.T) {
+	r := terr.NewRecorder(10)
+	file, line := getLocation(0)
+	err1 := terr.Newf("err1")
+	err2 := terr.Trace(err1)
+	r.Record(err1)
+	r.Record(err2)
+	recent := r.Recent()
+
+	var buf strings.Builder
+	assertErrorIsNil(t, r.Dump(&buf, terr.DumpText))
+	assertEquals(t, buf.String(), strings.Join([]string{
+		fmt.Sprintf("%s fingerprint %s", recent[0].Time.Format(time.RFC3339Nano), recent[0].Fingerprint),
+		fmt.Sprintf("err1 @ %s:%d", file, line+2),
+		fmt.Sprintf("\terr1 @ %s:%d", file, line+1),
+		"",
+		fmt.Sprintf("%s fingerprint %s", recent[1].Time.Format(time.RFC3339Nano), recent[1].Fingerprint),
+		fmt.Sprintf("err1 @ %s:%d", file, line+1),
+		"",
+		"",
+	}, "\n"))
+
+	buf.Reset()
+	assertErrorIsNil(t, r.Dump(&buf, terr.DumpJSON))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assertEquals(t, len(lines), 2)
+	var entry struct {
+		Time        time.Time       `json:"time"`
+		Fingerprint string          `json:"fingerprint"`
+		Error       json.RawMessage `json:"error"`
+	}
+	assertErrorIsNil(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assertEquals(t, entry.Time.Equal(recent[1].Time), true)
+	assertEquals(t, entry.Fingerprint, recent[1].Fingerprint)
+	assertEquals(t, string(entry.Error), fmt.Sprintf(`{"message":"err1","file":%q,"line":%d}`, file, line+1))
+}
+
+func TestDumpRecent(t *testing.T) {
+	err := terr.Newf("fail")
+	terr.DefaultRecorder.Record(err)
+
+	var buf strings.Builder
+	assertErrorIsNil(t, terr.DumpRecent(&buf, terr.DumpText))
+	assertEquals(t, strings.Contains(buf.String(), terr.Sprint(err)), true)
+}
+
+func TestRecorderHot(t *testing.T) {
+	r := terr.NewRecorder(1)
+	assertEquals(t, len(r.Hot(10, time.Minute)), 0)
+
+	file, line := getLocation(0)
+	newErr := func() error { return terr.Newf("fail") }
+	for i := 0; i < 3; i++ {
+		r.Record(terr.Trace(newErr()))
+	}
+	r.Record(terr.Newf("other"))
+	r.Record(errors.New("non-traced"))
+
+	hot := r.Hot(10, time.Minute)
+	assertEquals(t, len(hot), 2)
+	assertEquals(t, hot[0], terr.HotCallSite{CallSite: terr.CallSite{File: file, Line: line + 1}, Count: 3})
+	assertEquals(t, hot[1], terr.HotCallSite{CallSite: terr.CallSite{File: file, Line: line + 5}, Count: 1})
+	assertEquals(t, len(r.Hot(1, time.Hour)), 1)
+}
@@ -0,0 +1,49 @@
+package terr
+
+import (
+	"sync/atomic"
+)
+
+// Report describes a traced error being reported, i.e., having its error
+// tracing tree printed, formatted with the %@ verb, or emitted as structured
+// data.
+type Report struct {
+	// Code is the code of the reported error, as returned by Code.
+	Code string
+	// Fingerprint is the fingerprint of the reported error, as returned by
+	// Fingerprint.
+	Fingerprint string
+	// File and Line identify the location of the reported error.
+	File string
+	Line int
+}
+
+var reportHook atomic.Pointer[func(Report)]
+
+// OnReport registers fn to be called whenever a traced error is reported.
+// This can be used to feed metrics backends (e.g., counting errors by code
+// and fingerprint) without terr depending on any of them. Only one function
+// can be registered at a time, and passing nil disables reporting. fn may be
+// called concurrently and must not report traced errors itself.
+func OnReport(fn func(Report)) {
+	if fn == nil {
+		reportHook.Store(nil)
+		return
+	}
+	reportHook.Store(&fn)
+}
+
+// report invokes the registered report hook, if any, for e.
+func (e *tracedError) report() {
+	fn := reportHook.Load()
+	if fn == nil {
+		return
+	}
+	file, line := e.Location()
+	(*fn)(Report{
+		Code:        treeCode(e),
+		Fingerprint: e.getFingerprint(),
+		File:        file,
+		Line:        line,
+	})
+}
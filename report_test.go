@@ -0,0 +1,44 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestOnReport(t *testing.T) {
+	var reports []terr.Report
+	terr.OnReport(func(r terr.Report) {
+		reports = append(reports, r)
+	})
+	defer terr.OnReport(nil)
+
+	file, line := getLocation(0)
+	err := terr.Trace(terr.Newf("fail"), terr.WithCode("code"))
+	want := terr.Report{
+		Code:        "code",
+		Fingerprint: terr.Fingerprint(err),
+		File:        file,
+		Line:        line + 1,
+	}
+
+	_ = fmt.Sprintf("%@", err)
+	_ = terr.Sprint(err)
+	_ = terr.AppendTree(nil, err)
+	_, _ = json.Marshal(err)
+	// Other verbs do not report the error.
+	_ = fmt.Sprintf("%v", err)
+
+	assertEquals(t, len(reports), 4)
+	for _, r := range reports {
+		assertEquals(t, r, want)
+	}
+
+	terr.OnReport(nil)
+	_ = terr.Sprint(err)
+	assertEquals(t, len(reports), 4)
+}
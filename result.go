@@ -0,0 +1,31 @@
+package terr
+
+// Result holds either a value or an error, for passing the outcome of
+// operations through channels without losing where errors occurred.
+type Result[T any] struct {
+	value T
+	err   error
+}
+
+// Ok returns a successful Result holding value.
+func Ok[T any](value T) Result[T] {
+	return Result[T]{value: value}
+}
+
+// Err returns a failed Result holding err, traced where Err is called, so
+// the origin of errors sent through channels is recorded. If err is nil, the
+// Result is successful and holds the zero value of T.
+func Err[T any](err error) Result[T] {
+	if err == nil {
+		return Result[T]{}
+	}
+	if te := newTracedError(err, []any{err}, 0, nil); te != nil {
+		err = te
+	}
+	return Result[T]{err: err}
+}
+
+// Unwrap returns the value and error held by r.
+func (r Result[T]) Unwrap() (T, error) {
+	return r.value, r.err
+}
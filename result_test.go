@@ -0,0 +1,35 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestResult(t *testing.T) {
+	value, err := terr.Ok(42).Unwrap()
+	assertEquals(t, value, 42)
+	assertErrorIsNil(t, err)
+
+	value, err = terr.Err[int](nil).Unwrap()
+	assertEquals(t, value, 0)
+	assertErrorIsNil(t, err)
+
+	results := make(chan terr.Result[string], 1)
+	base := errors.New("fail")
+	file, line := getLocation(0)
+	go func() { results <- terr.Err[string](terr.Newf("worker: %w", base)) }()
+	s, err := (<-results).Unwrap()
+
+	assertEquals(t, s, "")
+	assertEquals(t, errors.Is(err, base), true)
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("worker: fail @ %s:%d", file, line+1),
+		fmt.Sprintf("\tworker: fail @ %s:%d", file, line+1),
+	}, "\n"))
+}
@@ -0,0 +1,94 @@
+package terr
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// ServiceInfo describes the service where traced errors occur.
+type ServiceInfo struct {
+	// Host is the name of the host running the service (e.g., as returned
+	// by os.Hostname).
+	Host string
+	// Environment is the name of the environment where the service runs
+	// (e.g., "production").
+	Environment string
+	// Labels are arbitrary static labels describing the service (e.g., its
+	// name, version or region).
+	Labels map[string]string
+}
+
+var serviceInfo atomic.Pointer[ServiceInfo]
+
+// SetServiceInfo stamps the roots of all error tracing trees emitted as
+// structured data (e.g., with json.Marshal, slog or Export) with info, so
+// exported trees are self-describing when collected from many services. Empty
+// fields are omitted. This function is safe for concurrent use, but it is
+// meant to be called once during program initialization:
+//
+//	host, _ := os.Hostname()
+//	terr.SetServiceInfo(terr.ServiceInfo{
+//		Host:        host,
+//		Environment: "production",
+//		Labels:      map[string]string{"service": "checkout"},
+//	})
+func SetServiceInfo(info ServiceInfo) {
+	if info.Host == "" && info.Environment == "" && len(info.Labels) == 0 {
+		serviceInfo.Store(nil)
+		return
+	}
+	labels := make(map[string]string, len(info.Labels))
+	for k, v := range info.Labels {
+		labels[k] = v
+	}
+	info.Labels = labels
+	serviceInfo.Store(&info)
+}
+
+// getServiceInfo returns the current service information, or nil if there
+// is none.
+func getServiceInfo() *ServiceInfo {
+	return serviceInfo.Load()
+}
+
+// sortedLabels returns the keys of the labels of info in sorted order.
+func (info *ServiceInfo) sortedLabels() []string {
+	keys := make([]string, 0, len(info.Labels))
+	for k := range info.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// appendServiceJSON appends the fields describing info to dst, each preceded
+// by a comma, using the given field names.
+func appendServiceJSON(dst []byte, info *ServiceInfo, names FieldNames) []byte {
+	if info.Host != "" {
+		dst = append(dst, ',')
+		dst = appendJSONString(dst, names.Host)
+		dst = append(dst, ':')
+		dst = appendJSONString(dst, info.Host)
+	}
+	if info.Environment != "" {
+		dst = append(dst, ',')
+		dst = appendJSONString(dst, names.Environment)
+		dst = append(dst, ':')
+		dst = appendJSONString(dst, info.Environment)
+	}
+	if len(info.Labels) > 0 {
+		dst = append(dst, ',')
+		dst = appendJSONString(dst, names.Labels)
+		dst = append(dst, ":{"...)
+		for i, k := range info.sortedLabels() {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, k)
+			dst = append(dst, ':')
+			dst = appendJSONString(dst, info.Labels[k])
+		}
+		dst = append(dst, '}')
+	}
+	return dst
+}
@@ -0,0 +1,42 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestSetServiceInfo(t *testing.T) {
+	labels := map[string]string{"service": "checkout", "region": "eu"}
+	terr.SetServiceInfo(terr.ServiceInfo{Host: "web-1", Environment: "production", Labels: labels})
+	defer terr.SetServiceInfo(terr.ServiceInfo{})
+	labels["service"] = "changed"
+
+	file, line := getLocation(0)
+	err := terr.Trace(terr.Newf("fail"))
+
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(b), fmt.Sprintf(`{"message":"fail","file":%q,"line":%d,`+
+		`"host":"web-1","environment":"production","labels":{"region":"eu","service":"checkout"},`+
+		`"children":[{"message":"fail","file":%q,"line":%d}]}`,
+		file, line+1, file, line+1))
+
+	assertEquals(t, string(terr.ExportLogfmt(err)), strings.Join([]string{
+		fmt.Sprintf("node=0 depth=0 msg=fail file=%s line=%d host=web-1 environment=production region=eu service=checkout", file, line+1),
+		fmt.Sprintf("node=1 parent=0 depth=1 msg=fail file=%s line=%d", file, line+1),
+		"",
+	}, "\n"))
+
+	terr.SetServiceInfo(terr.ServiceInfo{})
+	err = terr.Trace(errors.New("fail"))
+	b, jsonErr = json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, strings.Contains(string(b), "host"), false)
+}
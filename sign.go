@@ -0,0 +1,155 @@
+package terr
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrUntrustedTraceTree is wrapped by the errors returned by VerifyTraceTree
+// for signed error tracing trees whose signatures do not match any of the
+// given keys.
+var ErrUntrustedTraceTree = errors.New("untrusted error tracing tree")
+
+// SignTraceTree signs the JSON representation of an error tracing tree (e.g.,
+// as returned by Export) with key, using HMAC-SHA256. It returns a JSON
+// object with the compacted tree under the "tree" key and the hex-encoded
+// signature under the "signature" key, which can be embedded in error
+// responses, so services receiving them can check with VerifyTraceTree that
+// the tree was produced by a trusted service before logging or displaying it.
+// Returns nil if tree is not valid JSON.
+func SignTraceTree(tree, key []byte) []byte {
+	tree, ok := compactJSON(tree)
+	if !ok {
+		return nil
+	}
+	signed := append([]byte(`{"tree":`), tree...)
+	signed = append(signed, `,"signature":"`...)
+	signed = append(signed, hex.EncodeToString(signTree(tree, key))...)
+	return append(signed, `"}`...)
+}
+
+// VerifyTraceTree verifies an error tracing tree signed with SignTraceTree,
+// returning its JSON representation, which can be decoded with
+// DecodeTraceTree, if it was signed with any of the keys (so keys can be
+// rotated). The tree is checked byte by byte, so signed trees must not be
+// re-encoded in transit. Malformed input is rejected with a traced error of
+// kind Invalid wrapping ErrInvalidTraceTree, and trees with signatures not
+// matching any key with a traced error of kind Unauthenticated wrapping
+// ErrUntrustedTraceTree.
+func VerifyTraceTree(signed []byte, keys ...[]byte) ([]byte, error) {
+	tree, hexSignature, ok := parseSignedTraceTree(signed)
+	if !ok {
+		return nil, NewfWith([]TraceOption{WithKind(Invalid)}, "%w: malformed signed tree", ErrInvalidTraceTree)
+	}
+	signature, err := hex.DecodeString(hexSignature)
+	if err != nil {
+		return nil, NewfWith([]TraceOption{WithKind(Invalid)}, "%w: malformed signature", ErrInvalidTraceTree)
+	}
+	for _, key := range keys {
+		if hmac.Equal(signature, signTree(tree, key)) {
+			return tree, nil
+		}
+	}
+	return nil, NewfWith([]TraceOption{WithKind(Unauthenticated)}, "%w: signature mismatch", ErrUntrustedTraceTree)
+}
+
+// signTree returns the HMAC-SHA256 of tree with key.
+func signTree(tree, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(tree)
+	return mac.Sum(nil)
+}
+
+// parseSignedTraceTree returns the tree, as it was received, and the
+// signature in the envelope of an error tracing tree signed with
+// SignTraceTree, or false if the envelope is malformed. Like compactJSON, it
+// reads tokens instead of decoding nested values.
+func parseSignedTraceTree(signed []byte) (tree []byte, signature string, ok bool) {
+	dec := json.NewDecoder(bytes.NewReader(signed))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, "", false
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, "", false
+		}
+		start := dec.InputOffset()
+		switch tok {
+		case "signature":
+			tok, err := dec.Token()
+			if signature, ok = tok.(string); err != nil || !ok {
+				return nil, "", false
+			}
+		default:
+			if skipJSONValue(dec) != nil {
+				return nil, "", false
+			}
+			if tok == "tree" {
+				tree = bytes.TrimLeft(signed[start:dec.InputOffset()], " \t\r\n:")
+			}
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, "", false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, "", false
+	}
+	return tree, signature, len(tree) > 0
+}
+
+// compactJSON returns data without insignificant whitespace, as json.Compact
+// does, or false if data is not a single valid JSON value. Tokens are read
+// one at a time instead of decoding nested values, so arbitrarily deep values
+// cannot overflow the goroutine stack.
+func compactJSON(data []byte) ([]byte, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if skipJSONValue(dec) != nil {
+		return nil, false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, false
+	}
+	compact := make([]byte, 0, len(data))
+	inString, escaped := false, false
+	for _, c := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			inString, escaped = c != '"', c == '\\'
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			continue
+		case c == '"':
+			inString = true
+		}
+		compact = append(compact, c)
+	}
+	return compact, true
+}
+
+// skipJSONValue reads the next JSON value from dec, one token at a time.
+func skipJSONValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,45 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestSignTraceTree(t *testing.T) {
+	key, oldKey := []byte("secret"), []byte("old secret")
+	tree := terr.Export(terr.Newf("<nil> & more: %w", terr.Newf("fail")))
+	signed := terr.SignTraceTree(append([]byte(" "), tree...), key)
+
+	verified, err := terr.VerifyTraceTree(signed, oldKey, key)
+	assertErrorIsNil(t, err)
+	assertEquals(t, string(verified), string(tree))
+	et, err := terr.DecodeTraceTree(verified, terr.DecodeLimits{})
+	assertErrorIsNil(t, err)
+	assertEquals(t, et.Error(), "<nil> & more: fail")
+
+	_, err = terr.VerifyTraceTree(signed, oldKey)
+	assertEquals(t, errors.Is(err, terr.ErrUntrustedTraceTree), true)
+	assertEquals(t, terr.KindOf(err), terr.Unauthenticated)
+
+	tampered := []byte(string(signed[:len(`{"tree":{"message":"`)]) + "x" + string(signed[len(`{"tree":{"message":"`)+1:]))
+	_, err = terr.VerifyTraceTree(tampered, key)
+	assertEquals(t, errors.Is(err, terr.ErrUntrustedTraceTree), true)
+
+	spaced := strings.Replace(string(signed), `"tree":`, ` "tree" : `, 1)
+	verified, err = terr.VerifyTraceTree([]byte(spaced), key)
+	assertErrorIsNil(t, err)
+	assertEquals(t, string(verified), string(tree))
+
+	for _, data := range []string{``, `{}`, `[]`, `{"tree":{},"signature":"zz"}`, `{"tree":{},"signature":1}`, `{"tree":{}} {}`} {
+		_, err = terr.VerifyTraceTree([]byte(data), key)
+		assertEquals(t, errors.Is(err, terr.ErrInvalidTraceTree), true)
+		assertEquals(t, terr.KindOf(err), terr.Invalid)
+	}
+	assertEquals(t, terr.SignTraceTree([]byte("{"), key) == nil, true)
+	assertEquals(t, terr.SignTraceTree([]byte("{} {}"), key) == nil, true)
+}
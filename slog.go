@@ -0,0 +1,208 @@
+//go:build go1.21
+
+package terr
+
+import (
+	"log/slog"
+	"strconv"
+)
+
+// LogValue implements slog.LogValuer, representing the error tracing tree
+// rooted in e as nested slog groups. Children are keyed by their index. The
+// keys used can be configured with SetFieldNames.
+func (e *tracedError) LogValue() slog.Value {
+	e.report()
+	return logValue(e, getFieldNames(), getServiceInfo(), 1)
+}
+
+// appendServiceAttrs appends the attributes describing info to attrs.
+func appendServiceAttrs(attrs []slog.Attr, info *ServiceInfo, names FieldNames) []slog.Attr {
+	if info.Host != "" {
+		attrs = append(attrs, slog.String(names.Host, info.Host))
+	}
+	if info.Environment != "" {
+		attrs = append(attrs, slog.String(names.Environment, info.Environment))
+	}
+	if len(info.Labels) > 0 {
+		labels := make([]slog.Attr, 0, len(info.Labels))
+		for _, k := range info.sortedLabels() {
+			labels = append(labels, slog.String(k, info.Labels[k]))
+		}
+		attrs = append(attrs, slog.Attr{Key: names.Labels, Value: slog.GroupValue(labels...)})
+	}
+	return attrs
+}
+
+// logValue returns the slog representation of the error tracing tree rooted
+// in et, which was repeated count times, including service if it is not nil.
+// Structurally identical children are represented only once, along with the
+// number of times they were repeated. Nodes whose children are being
+// represented are kept in an explicit stack, so arbitrarily deep trees cannot
+// overflow the goroutine stack.
+func logValue(et ErrorTracer, names FieldNames, service *ServiceInfo, count int) slog.Value {
+	// slogFrame is a node whose children are being represented.
+	type slogFrame struct {
+		et       ErrorTracer
+		attrs    []slog.Attr
+		groups   []childGroup
+		children []slog.Attr
+	}
+	var frames []slogFrame
+	for {
+		groups := groupChildren(nil, childrenOf(et))
+		frames = append(frames, slogFrame{
+			et:       et,
+			attrs:    logAttrs(et, names, service, count),
+			groups:   groups,
+			children: make([]slog.Attr, 0, len(groups)),
+		})
+		service = nil
+
+		// Find the next group of children to represent, completing the
+		// nodes that have none left.
+		for {
+			f := &frames[len(frames)-1]
+			if len(f.children) < len(f.groups) {
+				et, count = f.groups[len(f.children)].ErrorTracer, f.groups[len(f.children)].count
+				break
+			}
+			attrs := f.attrs
+			if len(f.children) > 0 {
+				attrs = append(attrs, slog.Attr{
+					Key:   names.Children,
+					Value: slog.GroupValue(f.children...),
+				})
+			}
+			if omitted := OmittedChildren(f.et); omitted > 0 {
+				attrs = append(attrs, slog.Int(names.Omitted, omitted))
+			}
+			value := slog.GroupValue(attrs...)
+			frames = frames[:len(frames)-1]
+			if len(frames) == 0 {
+				return value
+			}
+			parent := &frames[len(frames)-1]
+			parent.children = append(parent.children, slog.Attr{
+				Key:   strconv.Itoa(len(parent.children)),
+				Value: value,
+			})
+		}
+	}
+}
+
+// logAttrs returns the slog attributes of et, which was repeated count times,
+// including service if it is not nil, except for its children and omitted
+// children.
+func logAttrs(et ErrorTracer, names FieldNames, service *ServiceInfo, count int) []slog.Attr {
+	file, line := et.Location()
+	message, metadata := et.Error(), metadataOf(et)
+	if isRedacted(et) {
+		message, metadata = redactedMessage(et), nil
+	}
+	var attrs []slog.Attr
+	if te, ok := et.(*tracedError); ok && te.id != "" {
+		attrs = append(attrs, slog.String(names.ID, te.id))
+	}
+	attrs = append(attrs, slog.String(names.Message, message))
+	switch {
+	case file == "":
+		// Untraced leaves have no location.
+	case names.Location != "":
+		attrs = append(attrs, slog.Group(names.Location,
+			slog.String(names.File, file),
+			slog.Int(names.Line, line),
+		))
+	default:
+		attrs = append(attrs,
+			slog.String(names.File, file),
+			slog.Int(names.Line, line),
+		)
+	}
+	if te, ok := et.(*tracedError); ok {
+		if code := te.fullCode(); code != "" {
+			attrs = append(attrs, slog.String(names.Code, code))
+		}
+		if te.kind != Unknown {
+			attrs = append(attrs, slog.String(names.Kind, te.kind.String()))
+		}
+		if te.domain != "" {
+			attrs = append(attrs, slog.String(names.Domain, te.domain))
+		}
+		if op := opOf(te); op != "" {
+			attrs = append(attrs, slog.String(names.Op, op))
+		}
+		if len(te.tags) > 0 {
+			attrs = append(attrs, slog.Any(names.Tags, te.tags))
+		}
+	}
+	if hints := hintsOf(et); len(hints) > 0 {
+		attrs = append(attrs, slog.Any(names.Hints, hints))
+	}
+	if url := URL(et); url != "" {
+		attrs = append(attrs, slog.String(names.URL, url))
+	}
+	if len(metadata) > 0 {
+		mdAttrs := make([]slog.Attr, len(metadata))
+		for i, md := range metadata {
+			mdAttrs[i] = slog.Any(md.key, md.value)
+		}
+		attrs = append(attrs, slog.Attr{
+			Key:   names.Metadata,
+			Value: slog.GroupValue(mdAttrs...),
+		})
+	}
+	if count > 1 {
+		attrs = append(attrs, slog.Int(names.Count, count))
+	}
+	if service != nil {
+		attrs = appendServiceAttrs(attrs, service, names)
+	}
+	return attrs
+}
+
+// ReplaceAttr can be used as the ReplaceAttr function of slog.HandlerOptions
+// to expand errors wrapping traced errors into groups, as LogValue does for
+// traced errors. The message of the group is the message of the logged error
+// (unless it must be redacted), while the rest of the group describes the
+// error tracing tree of the first traced error found in its Go error tree. It
+// is meant for applications that cannot replace their handlers, but can set
+// their options. Other attributes are returned unchanged, so it can be
+// chained with other ReplaceAttr functions:
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+//		ReplaceAttr: terr.ReplaceAttr,
+//	}))
+func ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+	err, ok := a.Value.Any().(error)
+	if !ok {
+		return a
+	}
+	te := findTraced(err)
+	if te == nil {
+		return a
+	}
+	te.report()
+	names := getFieldNames()
+	attrs := logValue(te, names, getServiceInfo(), 1).Group()
+	for i := range attrs {
+		if attrs[i].Key == names.Message && err != error(te) && !isRedacted(te) {
+			attrs[i] = slog.String(names.Message, err.Error())
+			break
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+}
+
+// Source returns the location of et as a slog.Source, so it can be used to
+// populate the source attribute of slog records. Its function is only set if
+// et implements FuncTracer and knows it. Returns nil if et is nil.
+func Source(et ErrorTracer) *slog.Source {
+	if et == nil {
+		return nil
+	}
+	file, line := et.Location()
+	return &slog.Source{Function: funcOf(et), File: file, Line: line}
+}
@@ -0,0 +1,153 @@
+//go:build go1.21 && !terr_noop
+
+package terr_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	file, line := getLocation(0)
+	err := terr.Trace(terr.Newf("fail"))
+	logger.Error("failed", "err", err)
+
+	assertEquals(t, buf.String(), fmt.Sprintf(`{"level":"ERROR","msg":"failed","err":`+
+		`{"message":"fail","file":%q,"line":%d,"children":{"0":`+
+		`{"message":"fail","file":%q,"line":%d}}}}`+"\n",
+		file, line+1, file, line+1))
+}
+
+func TestLogValueFieldNames(t *testing.T) {
+	terr.SetFieldNames(terr.FieldNames{File: "source", Children: "causes"})
+	defer terr.SetFieldNames(terr.FieldNames{})
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	file, line := getLocation(0)
+	err := terr.Trace(terr.Newf("fail"))
+	logger.Error("failed", "err", err)
+
+	assertEquals(t, buf.String(), fmt.Sprintf("level=ERROR msg=failed "+
+		"err.message=fail err.source=%s err.line=%d "+
+		"err.causes.0.message=fail err.causes.0.source=%s err.causes.0.line=%d\n",
+		file, line+1, file, line+1))
+}
+
+func TestLogValueNestedLocation(t *testing.T) {
+	terr.SetFieldNames(terr.GCPFieldNames)
+	defer terr.SetFieldNames(terr.FieldNames{})
+
+	file, line := getLocation(0)
+	err := terr.Newf("fail")
+
+	value := terr.TraceTree(err).(slog.LogValuer).LogValue()
+	assertEquals(t, value.String(), fmt.Sprintf("[message=fail logging.googleapis.com/sourceLocation=[file=%s line=%d]]",
+		file, line+1))
+}
+
+func TestLogValueServiceInfo(t *testing.T) {
+	terr.SetServiceInfo(terr.ServiceInfo{Host: "web-1", Labels: map[string]string{"service": "checkout"}})
+	defer terr.SetServiceInfo(terr.ServiceInfo{})
+
+	file, line := getLocation(0)
+	err := terr.Trace(terr.Newf("fail"))
+
+	value := terr.TraceTree(err).(slog.LogValuer).LogValue()
+	assertEquals(t, value.String(), fmt.Sprintf("[message=fail file=%s line=%d host=web-1 labels=[service=checkout] "+
+		"children=[0=[message=fail file=%s line=%d]]]", file, line+1, file, line+1))
+}
+
+func TestLogValueRedacted(t *testing.T) {
+	err := terr.Newf("secret")
+	err = terr.Trace(err, terr.WithCode("failed"), terr.WithMetadata("user", "alice"),
+		terr.WithConfig(terr.Config{Redact: true}))
+
+	value := terr.TraceTree(err).(slog.LogValuer).LogValue()
+	attrs := value.Group()
+	assertEquals(t, attrs[0].Value.String(), "failed")
+	for _, attr := range attrs {
+		assertEquals(t, attr.Key != "metadata", true)
+	}
+}
+
+func TestLogValueDeepTree(t *testing.T) {
+	err := deepTree()
+
+	// Traversing the tree recursively would exceed this limit.
+	maxStack := debug.SetMaxStack(256 << 10)
+	defer debug.SetMaxStack(maxStack)
+	value := slog.AnyValue(err).Resolve()
+	debug.SetMaxStack(maxStack)
+
+	// Follow the first children down to the root cause.
+	depth := 0
+	for attrs := value.Group(); attrs != nil; depth++ {
+		last := attrs[len(attrs)-1]
+		attrs = nil
+		if last.Key == "children" {
+			attrs = last.Value.Group()[0].Value.Group()
+		}
+	}
+	assertEquals(t, depth, deepTreeDepth+1)
+}
+
+func TestReplaceAttr(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return terr.ReplaceAttr(groups, a)
+		},
+	}))
+
+	file, line := getLocation(0)
+	err := fmt.Errorf("request failed: %w", terr.Newf("fail"))
+	logger.Error("failed", "err", err, "other", errors.New("plain"), "n", 1)
+
+	assertEquals(t, buf.String(), fmt.Sprintf(`{"level":"ERROR","msg":"failed","err":`+
+		`{"message":"request failed: fail","file":%q,"line":%d},"other":"plain","n":1}`+"\n",
+		file, line+1))
+}
+
+func TestSource(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Newf("fail")
+
+	source := terr.Source(terr.TraceTree(err))
+	assertEquals(t, source.File, file)
+	assertEquals(t, source.Line, line+1)
+	assertEquals(t, source.Function, "github.com/alnvdl/terr_test.TestSource")
+	assertEquals(t, terr.Source(terr.TraceTree(nil)) == nil, true)
+
+	remote := terr.NewfWith([]terr.TraceOption{terr.WithLocation("remote.go", 42)}, "fail")
+	source = terr.Source(terr.TraceTree(remote))
+	assertEquals(t, source.File, "remote.go")
+	assertEquals(t, source.Function, "")
+}
@@ -0,0 +1,102 @@
+package terr
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// ParseStack converts the textual representation of goroutine stacks, as
+// returned by runtime/debug.Stack or printed by unrecovered panics, into a
+// traced error, so recovered panics can be reported like other traced
+// errors. The root of the error tracing tree has message as its message, or
+// the panic message found in stack if message is empty, and is located at
+// the function that panicked, if any. It has one child per goroutine, and
+// each goroutine has one child per stack frame, from the innermost to the
+// outermost call. Returns nil if no goroutines are found in stack.
+//
+// It is meant to be used when recovering from panics:
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			err = terr.ParseStack(debug.Stack(), fmt.Sprint(r))
+//		}
+//	}()
+func ParseStack(stack []byte, message string) error {
+	var goroutines []*Builder
+	var goroutine *Builder
+	var location *Builder
+	var function string
+	var frames int
+	scanner := bufio.NewScanner(bytes.NewReader(stack))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "panic: "):
+			if message == "" {
+				message = strings.TrimSuffix(strings.TrimPrefix(line, "panic: "), " [recovered]")
+			}
+		case strings.HasPrefix(line, "goroutine ") && strings.HasSuffix(line, ":"):
+			goroutine = NewBuilder(strings.TrimSuffix(line, ":"))
+			goroutines = append(goroutines, goroutine)
+			frames, function = 0, ""
+		case goroutine == nil || line == "":
+			function = ""
+		case strings.HasPrefix(line, "\t") && function != "":
+			file, lineNum := parseStackLocation(strings.TrimPrefix(line, "\t"))
+			frame := NewBuilder(function).Location(file, lineNum)
+			if frames == 0 {
+				goroutine.Location(file, lineNum)
+			}
+			// The root is located at the first frame of the first goroutine
+			// after the last panic call, or at its first frame outside the
+			// runtime if it did not panic.
+			if len(goroutines) == 1 {
+				switch {
+				case function == "panic":
+					location = nil
+				case location == nil && !strings.HasPrefix(function, "runtime.") &&
+					!strings.HasPrefix(function, "runtime/debug."):
+					location = frame
+				}
+			}
+			goroutine.Child(frame)
+			frames++
+			function = ""
+		default:
+			function = line
+			if i := strings.LastIndexByte(line, '('); i > 0 && strings.HasSuffix(line, ")") {
+				function = line[:i]
+			}
+		}
+	}
+	if len(goroutines) == 0 {
+		return nil
+	}
+	if message == "" {
+		message = "panic"
+	}
+	root := NewBuilder(message).Child(goroutines...)
+	if location != nil {
+		root.Location(location.file, location.line)
+	}
+	return root.Build()
+}
+
+// parseStackLocation parses a location line in a goroutine stack, like
+// "/src/main.go:10 +0x1d", into its file and line.
+func parseStackLocation(s string) (string, int) {
+	if i := strings.LastIndex(s, " +0x"); i >= 0 {
+		s = s[:i]
+	}
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return s, 0
+	}
+	line, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return s, 0
+	}
+	return s[:i], line
+}
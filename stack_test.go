@@ -0,0 +1,59 @@
+package terr_test
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+const panicOutput = `panic: boom [recovered]
+
+goroutine 1 [running]:
+main.T.m(...)
+	/src/main.go:10
+main.main()
+	/src/main.go:17 +0x3f
+
+goroutine 6 [chan receive]:
+main.worker(0xc000012345)
+	/src/worker.go:5 +0x25
+created by main.main in goroutine 1
+	/src/main.go:14 +0x65
+`
+
+func TestParseStack(t *testing.T) {
+	err := terr.ParseStack([]byte(panicOutput), "")
+	assertEquals(t, terr.Sprint(err), strings.Join([]string{
+		"boom @ /src/main.go:10",
+		"\tgoroutine 1 [running] @ /src/main.go:10",
+		"\t\tmain.T.m @ /src/main.go:10",
+		"\t\tmain.main @ /src/main.go:17",
+		"\tgoroutine 6 [chan receive] @ /src/worker.go:5",
+		"\t\tmain.worker @ /src/worker.go:5",
+		"\t\tcreated by main.main in goroutine 1 @ /src/main.go:14",
+	}, "\n"))
+
+	assertEquals(t, terr.ParseStack([]byte(panicOutput), "custom").Error(), "custom")
+	assertErrorIsNil(t, terr.ParseStack([]byte("no stacks here"), "fail"))
+}
+
+func TestParseStackRecovered(t *testing.T) {
+	file, line := getLocation(0)
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = terr.ParseStack(debug.Stack(), fmt.Sprint(r))
+			}
+		}()
+		panic("boom")
+	}()
+
+	gotFile, gotLine := terr.TraceTree(err).Location()
+	assertEquals(t, err.Error(), "boom")
+	assertEquals(t, gotFile, file)
+	assertEquals(t, gotLine, line+8)
+}
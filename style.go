@@ -0,0 +1,79 @@
+package terr
+
+import (
+	"sync/atomic"
+)
+
+// TreeStyle defines the layout used when printing error tracing trees.
+type TreeStyle struct {
+	// Indent is repeated once per level of depth to indent traced errors.
+	// Defaults to a tab.
+	Indent string
+	// Separator separates error messages from their locations. Defaults to
+	// " @ ".
+	Separator string
+	// Connectors is whether box-drawing connectors (e.g., "├── ") are used to
+	// draw the tree instead of Indent.
+	Connectors bool
+	// Verbose is whether traced errors classified as internal by the filter
+	// set with SetInternalFrames are printed, as with the %+@ verb.
+	Verbose bool
+	// Functions is whether the functions of traced errors implementing
+	// FuncTracer are printed after their locations, as with the %+@ verb.
+	Functions bool
+	// Order is the order in which children are printed. Defaults to the
+	// order in which they were added.
+	Order ChildOrder
+}
+
+var defaultTreeStyle = TreeStyle{Indent: "\t", Separator: " @ "}
+
+// verboseTreeStyle is the default TreeStyle, but printing internal traced
+// errors.
+var verboseTreeStyle = TreeStyle{Indent: "\t", Separator: " @ ", Verbose: true}
+
+var treeStyle atomic.Pointer[TreeStyle]
+
+// SetTreeStyle configures the layout used when printing error tracing trees
+// for all traced errors, whether with the %@ verb or functions like Sprint.
+// Empty fields are replaced by their defaults. This function is safe for
+// concurrent use, but it is meant to be called once during program
+// initialization.
+func SetTreeStyle(style TreeStyle) {
+	if style.Indent == "" {
+		style.Indent = defaultTreeStyle.Indent
+	}
+	if style.Separator == "" {
+		style.Separator = defaultTreeStyle.Separator
+	}
+	if style == defaultTreeStyle {
+		treeStyle.Store(nil)
+		return
+	}
+	treeStyle.Store(&style)
+}
+
+// getTreeStyle returns the currently configured tree style.
+func getTreeStyle() *TreeStyle {
+	if style := treeStyle.Load(); style != nil {
+		return style
+	}
+	return &defaultTreeStyle
+}
+
+// rootIndent returns the indentation for the details and children of the
+// root of a tree printed with style.
+func (style *TreeStyle) rootIndent() string {
+	if style.Connectors {
+		return ""
+	}
+	return style.Indent
+}
+
+// Box-drawing connectors used when TreeStyle.Connectors is set.
+const (
+	connectorBranch = "├── "
+	connectorLast   = "└── "
+	connectorPipe   = "│   "
+	connectorSpace  = "    "
+)
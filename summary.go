@@ -0,0 +1,131 @@
+package terr
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Summary aggregates the traced errors recorded by a SummaryReporter during
+// an interval.
+type Summary struct {
+	// Start and End delimit the interval.
+	Start, End time.Time
+	// Entries count the errors by fingerprint, from the most to the least
+	// frequent.
+	Entries []SummaryEntry
+}
+
+// SummaryEntry counts the errors with a given fingerprint in a Summary.
+type SummaryEntry struct {
+	// Fingerprint is the fingerprint shared by the errors, as returned by
+	// Fingerprint.
+	Fingerprint string
+	// Code is the code of the first of the errors, as returned by Code.
+	Code string
+	// Message is the message of the first of the errors.
+	Message string
+	// Count is the number of errors.
+	Count int
+}
+
+// SummaryReporter periodically reports summaries of the traced errors it
+// recorded, counted by fingerprint, giving long-running processes visibility
+// into their errors without logging each one of them. A SummaryReporter is
+// safe for concurrent use.
+type SummaryReporter struct {
+	sink     func(Summary)
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+
+	mu      sync.Mutex
+	start   time.Time
+	entries map[string]*SummaryEntry
+}
+
+// StartSummaryReporter returns a SummaryReporter calling sink with a summary
+// of the errors recorded in each interval, from a background goroutine.
+// Intervals without errors are not reported. Stop must be called to stop the
+// background goroutine.
+func StartSummaryReporter(interval time.Duration, sink func(Summary)) *SummaryReporter {
+	r := &SummaryReporter{
+		sink:    sink,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		start:   time.Now(),
+		entries: make(map[string]*SummaryEntry),
+	}
+	go r.run(interval)
+	return r
+}
+
+// run flushes r every interval until it is stopped.
+func (r *SummaryReporter) run(interval time.Duration) {
+	defer close(r.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.flush()
+		case <-r.stop:
+			r.flush()
+			return
+		}
+	}
+}
+
+// Record counts err in the current summary if it is a traced error.
+// Non-traced errors are ignored.
+func (r *SummaryReporter) Record(err error) {
+	et := TraceTree(err)
+	if et == nil {
+		return
+	}
+	fingerprint := treeFingerprint(et)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[fingerprint]
+	if !ok {
+		entry = &SummaryEntry{Fingerprint: fingerprint, Code: treeCode(et), Message: et.Error()}
+		r.entries[fingerprint] = entry
+	}
+	entry.Count++
+}
+
+// Stop reports the summary of the errors recorded since the last one, if
+// any, and stops the background goroutine. Errors recorded after Stop
+// returns are never reported. Calling Stop again does nothing.
+func (r *SummaryReporter) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	<-r.done
+}
+
+// flush reports the current summary, if it has any errors, and starts a new
+// one.
+func (r *SummaryReporter) flush() {
+	r.mu.Lock()
+	summary := Summary{Start: r.start, End: time.Now()}
+	for _, entry := range r.entries {
+		summary.Entries = append(summary.Entries, *entry)
+	}
+	r.start = summary.End
+	r.entries = make(map[string]*SummaryEntry)
+	r.mu.Unlock()
+
+	if len(summary.Entries) == 0 {
+		return
+	}
+	sort.Slice(summary.Entries, func(i, j int) bool {
+		a, b := summary.Entries[i], summary.Entries[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+	r.sink(summary)
+}
@@ -0,0 +1,53 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestSummaryReporter(t *testing.T) {
+	summaries := make(chan terr.Summary, 10)
+	r := terr.StartSummaryReporter(time.Hour, func(s terr.Summary) { summaries <- s })
+
+	newErr := func() error { return terr.Trace(errors.New("fail"), terr.WithCode("failed")) }
+	start := time.Now()
+	r.Record(newErr())
+	r.Record(terr.Newf("other"))
+	r.Record(newErr())
+	r.Record(errors.New("non-traced"))
+	r.Stop()
+
+	assertEquals(t, len(summaries), 1)
+	s := <-summaries
+	assertEquals(t, s.Start.After(start), false)
+	assertEquals(t, s.End.Before(start), false)
+	assertEquals(t, len(s.Entries), 2)
+	assertEquals(t, s.Entries[0], terr.SummaryEntry{
+		Fingerprint: terr.Fingerprint(newErr()), Code: "failed", Message: "fail", Count: 2,
+	})
+	assertEquals(t, s.Entries[1].Message, "other")
+	assertEquals(t, s.Entries[1].Count, 1)
+
+	// Stopping again does nothing.
+	r.Stop()
+	assertEquals(t, len(summaries), 0)
+}
+
+func TestSummaryReporterInterval(t *testing.T) {
+	summaries := make(chan terr.Summary, 10)
+	r := terr.StartSummaryReporter(time.Millisecond, func(s terr.Summary) { summaries <- s })
+	defer r.Stop()
+
+	r.Record(terr.Newf("fail"))
+	select {
+	case s := <-summaries:
+		assertEquals(t, s.Entries[0].Count, 1)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no summary was reported")
+	}
+}
@@ -0,0 +1,53 @@
+package terr
+
+import "errors"
+
+// FuncMap returns functions for rendering errors in text/template and
+// html/template templates, so alerting templates and status pages can render
+// traced errors without preformatting them:
+//   - traceTree returns the error tracing tree for an error, as Sprint does;
+//   - traceJSON returns the JSON representation of the error tracing tree for
+//     an error, as MarshalJSON does, so traced errors wrapped by non-traced
+//     errors are also found, and errors without traced errors are
+//     represented as objects with only their message;
+//   - rootCause returns the root cause of an error, which is the first leaf of
+//     its error tracing tree for traced errors, or the innermost error in the
+//     chain defined by errors.Unwrap for non-traced errors.
+//
+// The returned map can be passed directly to the Funcs method of templates:
+//
+//	tmpl := template.Must(template.New("alert").Funcs(terr.FuncMap()).Parse(
+//		"Request failed:\n{{traceTree .Err}}\nCause: {{rootCause .Err}}\n"))
+func FuncMap() map[string]any {
+	return map[string]any{
+		"traceTree": Sprint,
+		"traceJSON": traceJSON,
+		"rootCause": rootCause,
+	}
+}
+
+// traceJSON returns the JSON representation of the error tracing tree for
+// err, as returned by MarshalJSON.
+func traceJSON(err error) string {
+	b, _ := MarshalJSON(err)
+	return string(b)
+}
+
+// rootCause returns the root cause of err.
+func rootCause(err error) error {
+	if te, ok := err.(*tracedError); ok && te != nil {
+		var et ErrorTracer = te
+		for children := childrenOf(et); len(children) > 0; children = childrenOf(et) {
+			et = children[0]
+		}
+		return et
+	}
+	for err != nil {
+		next := errors.Unwrap(err)
+		if next == nil {
+			break
+		}
+		err = next
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestFuncMap(t *testing.T) {
+	tmpl := template.Must(template.New("alert").Funcs(terr.FuncMap()).Parse(
+		"{{traceTree .}}\n{{traceJSON .}}\n{{rootCause .}}"))
+
+	file, line := getLocation(0)
+	cause := terr.Newf("disk full")
+	err := terr.Newf("save failed: %w", cause)
+
+	var b strings.Builder
+	assertErrorIsNil(t, tmpl.Execute(&b, err))
+	assertEquals(t, b.String(), strings.Join([]string{
+		fmt.Sprintf("save failed: disk full @ %s:%d", file, line+2),
+		fmt.Sprintf("\tdisk full @ %s:%d", file, line+1),
+		fmt.Sprintf(`{"message":"save failed: disk full","file":%q,"line":%d,"children":[{"message":"disk full","file":%q,"line":%d}]}`,
+			file, line+2, file, line+1),
+		"disk full",
+	}, "\n"))
+}
+
+func TestFuncMapNonTraced(t *testing.T) {
+	tmpl := template.Must(template.New("alert").Funcs(terr.FuncMap()).Parse(
+		"{{traceTree .}}\n{{traceJSON .}}\n{{rootCause .}}"))
+
+	var b strings.Builder
+	err := fmt.Errorf("save failed: %w", errors.New("disk full"))
+	assertErrorIsNil(t, tmpl.Execute(&b, err))
+	assertEquals(t, b.String(), "save failed: disk full\n"+
+		`{"message":"save failed: disk full"}`+"\ndisk full")
+
+	// Traced errors wrapped by non-traced errors are found.
+	b.Reset()
+	jsonTmpl := template.Must(template.New("json").Funcs(terr.FuncMap()).Parse("{{traceJSON .}}"))
+	file, line := getLocation(0)
+	err = fmt.Errorf("save failed: %w", terr.Newf("disk full"))
+	assertErrorIsNil(t, jsonTmpl.Execute(&b, err))
+	assertEquals(t, b.String(), fmt.Sprintf(`{"message":"disk full","file":%q,"line":%d}`, file, line+1))
+
+	b.Reset()
+	assertErrorIsNil(t, tmpl.Execute(&b, nil))
+	assertEquals(t, b.String(), "<nil>\nnull\n<nil>")
+}
+
+func TestFuncMapHTML(t *testing.T) {
+	tmpl := htmltemplate.Must(htmltemplate.New("page").Funcs(terr.FuncMap()).Parse(
+		"<pre>{{traceTree .}}</pre>"))
+
+	var b strings.Builder
+	assertErrorIsNil(t, tmpl.Execute(&b, errors.New("<script>")))
+	assertEquals(t, b.String(), "<pre>&lt;script&gt;</pre>")
+}
@@ -4,8 +4,11 @@ package terr
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"runtime"
-	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // tracedError implements the error and ErrorTracer interfaces, while being
@@ -13,33 +16,236 @@ import (
 // standard library by implementing Is, As, Unwrap and Format.
 type tracedError struct {
 	error
-	location
+	// pc is the program counter identifying the location of the traced
+	// error. It is only resolved into a file and line when needed, since
+	// resolving it is much more expensive than capturing it.
+	pc uintptr
+	// loc caches the resolved location of the traced error.
+	loc atomic.Pointer[location]
+	// stack holds the program counters of the call stack of the traced
+	// error, from its location outwards, if Config.Stack was set.
+	stack    []uintptr
 	children []ErrorTracer
+	// omitted is the number of children that were not recorded due to the
+	// limit set with SetMaxChildren.
+	omitted int
+	code    string
+	kind    Kind
+	domain  string
+	op      string
+	// autoOp is whether the operation of this traced error is the name of
+	// the function where it was created.
+	autoOp bool
+	tags   []string
+	hints  []string
+	url    string
+	id     string
+	// time is the time when the traced error occurred, if it was imported
+	// from external data with WithTime.
+	time     time.Time
+	metadata []metadatum
+	// redacted is whether the message and metadata of this traced error
+	// must be redacted in structured output.
+	redacted bool
+	// config is the Config set with WithConfig or SetDefaults, which is only
+	// used while the traced error is being created.
+	config *Config
+	// targets caches the errors in the Go error tree of the traced error,
+	// as collected for Is.
+	targets atomic.Pointer[isTargets]
+	// repr caches the representation of the error tracing tree rooted in
+	// this traced error. Traced errors are immutable, so it only needs to be
+	// recomputed if the tree style changes.
+	repr atomic.Pointer[treeRepr]
+	// fingerprint caches the fingerprint of the error tracing tree rooted in
+	// this traced error.
+	fingerprint atomic.Pointer[string]
+	// shape caches the shape key of the error tracing tree rooted in this
+	// traced error, as computed by treeShape.
+	shape atomic.Pointer[shapeKey]
+}
+
+// treeRepr is a representation of an error tracing tree in a given style,
+// hiding the traced errors classified as internal by a filter.
+type treeRepr struct {
+	style    *TreeStyle
+	internal *func(string) bool
+	s        string
 }
 
 type location struct {
-	file string
-	line int
+	file     string
+	line     int
+	function string
 }
 
-func getCallerLocation(skip int) location {
-	_, file, line, _ := runtime.Caller(2 + skip)
-	return location{file, line}
+// unknownLocation is the location of traced errors whose callers could not be
+// identified, e.g., due to runtimes without caller information, using the
+// same placeholder as the log package.
+var unknownLocation = location{file: "???"}
+
+// locations caches resolved locations by program counter. Since the number
+// of call sites in a program is bounded, it never needs to be pruned.
+var locations sync.Map // map[uintptr]*location
+
+// getCallerPC returns the program counter of the caller, skipping a number of
+// stack frames. It returns false if no traced error should be created at that
+// location due to rate limiting.
+func getCallerPC(skip int) (uintptr, bool) {
+	var pcs [1]uintptr
+	runtime.Callers(3+skip, pcs[:])
+	if !allowTrace(pcs[0]) {
+		return 0, false
+	}
+	return pcs[0], true
 }
 
-func newTracedError(err error, children []any, loc location) *tracedError {
-	terr := &tracedError{err, loc, nil}
+// resolveLocation returns the location of e, resolving it from its program
+// counter only once per call site, with its file path trimmed to the depth
+// set with SetPathDepth. If the program counter is unknown or
+// cannot be resolved, unknownLocation is returned.
+func (e *tracedError) resolveLocation() *location {
+	if loc := e.loc.Load(); loc != nil {
+		return loc
+	}
+	var loc *location
+	if cached, ok := locations.Load(e.pc); ok {
+		loc = cached.(*location)
+	} else {
+		loc = &unknownLocation
+		if e.pc != 0 {
+			if frame, _ := runtime.CallersFrames([]uintptr{e.pc}).Next(); frame.File != "" {
+				loc = &location{trimPath(frame.File), frame.Line, frame.Function}
+			}
+		}
+		locations.Store(e.pc, loc)
+	}
+	e.loc.Store(loc)
+	return loc
+}
+
+// newTracedError returns a new traced error for err, located at the caller of
+// the function calling newTracedError, skipping a number of additional stack
+// frames. Traced errors among children are included as children of the new
+// traced error, and so are the errors combined by aggregate errors among
+// children, as well as other errors among children if SetUntracedLeaves was
+// enabled. Returns nil if no traced error should be created due to rate
+// limiting or sampling, or if tracing is compiled out.
+func newTracedError(err error, children []any, skip int, opts []TraceOption) *tracedError {
+	if noop {
+		return nil
+	}
+	pc, ok := getCallerPC(1 + skip)
+	if !ok {
+		return nil
+	}
+	terr := &tracedError{error: err, pc: pc}
 	for _, child := range children {
-		if child, ok := child.(*tracedError); ok {
-			terr.children = append(terr.children, child)
+		switch child := child.(type) {
+		case *tracedError:
+			terr.addChild(child)
+		case error:
+			if errs := aggregatedErrors(child); errs != nil {
+				terr.addAggregated(errs)
+			} else if untracedLeaves.Load() && !sameError(child, err) && findTraced(child) == nil {
+				terr.addChild(untracedLeaf(child))
+			}
+		case []error:
+			terr.addAggregated(child)
 		}
 	}
+	terr.applyDefaults()
+	for _, opt := range opts {
+		opt(terr)
+	}
+	if terr.config != nil && !terr.applyConfig(2+skip) {
+		return nil
+	}
+	terr.traceEvent()
 	return terr
 }
 
+// addChild records child as a child of e, unless the limit set with
+// SetMaxChildren has been reached, in which case only the number of omitted
+// children is increased.
+func (e *tracedError) addChild(child ErrorTracer) {
+	if max := maxChildren.Load(); max > 0 && int64(len(e.children)) >= max {
+		e.omitted++
+		return
+	}
+	e.children = append(e.children, child)
+}
+
 // Is returns whether the error is another error for use with errors.Is.
+// Traced errors are compared by identity first. Otherwise, unless the Go
+// error tree of e has errors with their own Is methods, target is looked up
+// among the errors in that tree, which are collected only once, so checking
+// deeply nested traced errors against sentinel errors does not repeat full
+// traversals.
 func (e *tracedError) Is(target error) bool {
-	return errors.Is(e.error, target)
+	if te, ok := target.(*tracedError); ok && te == e {
+		return true
+	}
+	targets := e.targets.Load()
+	if targets == nil {
+		targets = collectTargets(e.error)
+		e.targets.Store(targets)
+	}
+	if targets.custom || target == nil {
+		return errors.Is(e.error, target)
+	}
+	if !reflect.TypeOf(target).Comparable() {
+		return false
+	}
+	for _, err := range targets.errs {
+		if err == target {
+			return true
+		}
+	}
+	return false
+}
+
+// isTargets holds the errors errors.Is compares targets with in the Go error
+// tree of a traced error.
+type isTargets struct {
+	// errs are the comparable errors in the tree.
+	errs []error
+	// custom is whether any error in the tree has its own Is method, in
+	// which case errors.Is must be used.
+	custom bool
+}
+
+// collectTargets returns the errors in the Go error tree of err, as visited
+// by errors.Is. Traced errors are replaced by the errors they trace, since
+// their Is methods are equivalent to errors.Is on them.
+func collectTargets(err error) *isTargets {
+	targets := &isTargets{}
+	stack := []error{err}
+	for len(stack) > 0 {
+		err := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if err == nil {
+			continue
+		}
+		if reflect.TypeOf(err).Comparable() {
+			targets.errs = append(targets.errs, err)
+		}
+		switch err := err.(type) {
+		case *tracedError:
+			stack = append(stack, err.error)
+		case interface{ Is(error) bool }:
+			targets.custom = true
+			return targets
+		case interface{ Unwrap() error }:
+			stack = append(stack, err.Unwrap())
+		case interface{ Unwrap() []error }:
+			errs := err.Unwrap()
+			for i := len(errs) - 1; i >= 0; i-- {
+				stack = append(stack, errs[i])
+			}
+		}
+	}
+	return targets
 }
 
 // As returns the error as another error for use with errors.As.
@@ -52,6 +258,12 @@ func (e *tracedError) Unwrap() error {
 	return errors.Unwrap(e.error)
 }
 
+// Cause returns the error traced by e. It makes traced errors transparent to
+// functions following the github.com/pkg/errors Cause convention.
+func (e *tracedError) Cause() error {
+	return e.error
+}
+
 // Error implements the error interface.
 func (e *tracedError) Error() string {
 	return e.error.Error()
@@ -59,71 +271,206 @@ func (e *tracedError) Error() string {
 
 // Location implements the ErrorTracer interface.
 func (e *tracedError) Location() (string, int) {
-	return e.file, e.line
+	loc := e.resolveLocation()
+	return loc.file, loc.line
+}
+
+// Func implements the FuncTracer interface.
+func (e *tracedError) Func() string {
+	return e.resolveLocation().function
 }
 
-// Children implements the ErrorTracer interface.
+// Children implements the ErrorTracer interface. It returns a copy of the
+// children of e, so callers cannot modify the error tracing tree, which can
+// therefore be read concurrently.
 func (e *tracedError) Children() []ErrorTracer {
-	return e.children
+	if len(e.children) == 0 {
+		return nil
+	}
+	return append([]ErrorTracer(nil), e.children...)
 }
 
-// Format implements fmt.Formatter.
+// childrenOf returns the children of et without copying them if et is a
+// traced error. The returned slice must not be modified.
+func childrenOf(et ErrorTracer) []ErrorTracer {
+	if te, ok := et.(*tracedError); ok {
+		return te.children
+	}
+	return et.Children()
+}
+
+// treeVerb is the formatting verb set with SetTreeVerb. Zero means '@'.
+var treeVerb atomic.Int32
+
+// SetTreeVerb sets the formatting verb that prints error tracing trees for
+// all traced errors, instead of '@', which can conflict with some printf-style
+// linters and logging layers. Verbs used by fmt for errors (e.g., 'v' and 's')
+// should be avoided, since they would no longer print plain error messages.
+// Passing 0 restores the default.
+func SetTreeVerb(verb rune) {
+	treeVerb.Store(verb)
+}
+
+// Format implements fmt.Formatter. The %@ verb prints the error tracing tree
+// rooted in e, and %#@ prints it with children sharing the same root cause
+// grouped, showing only the first of them along with the size of the group.
+// %+@ also prints the traced errors hidden by SetInternalFrames and the
+// functions where traced errors were created, %-@ prints the tree in a single
+// line (e.g., "a @ x.go:1 -> [b @ x.go:2; c @ x.go:3]"), and a precision
+// limits the number of levels printed (e.g., %.2@ prints e and its children),
+// counting the children below them as omitted. Flags and precision can be
+// combined, as in %-.3@. The verb can be changed with SetTreeVerb. The %+v
+// verb prints the tree as %@ does, so logging code written for packages like
+// github.com/pkg/errors shows it without changes, while %v prints only the
+// message. The %#v verb prints the representation returned by GoString.
 func (e *tracedError) Format(f fmt.State, verb rune) {
-	if verb == '@' {
-		fmt.Fprint(f, strings.Join(treeRepr(e, 0), "\n"))
+	tv := treeVerb.Load()
+	if tv == 0 {
+		tv = '@'
+	}
+	if verb == tv {
+		e.report()
+		style := getTreeStyle()
+		if f.Flag('+') && !(style.Verbose && style.Functions) {
+			verbose := *style
+			verbose.Verbose, verbose.Functions = true, true
+			style = &verbose
+		}
+		depth, _ := f.Precision()
+		switch {
+		case f.Flag('-'):
+			f.Write(appendCompactTree(nil, e, style, f.Flag('#'), depth))
+		case f.Flag('#') || f.Flag('+') || depth > 0:
+			f.Write(appendNode(nil, e, style, []byte(style.rootIndent()), 1, f.Flag('#'), depth))
+		default:
+			fmt.Fprint(f, e.tree())
+		}
+		return
+	}
+	if verb == 'v' && f.Flag('#') {
+		fmt.Fprint(f, e.GoString())
+		return
+	}
+	if verb == 'v' && f.Flag('+') {
+		e.report()
+		fmt.Fprint(f, e.tree())
 		return
 	}
 	fmt.Fprintf(f, fmt.FormatString(f, verb), e.error)
 }
 
-// treeRepr returns a tab-indented, multi-line representation of a traced error
-// tree rooted in err.
-func treeRepr(err error, depth int) []string {
-	var locations []string
-	te := err.(*tracedError)
-	// No need to check the cast was successful: treeRepr is only invoked
-	// internally via tracedError.Format. If that pre-condition is ever
-	// violated, a panic is warranted.
-	file, line := te.Location()
-	locations = append(locations, fmt.Sprintf("%s%s @ %s",
-		strings.Repeat("\t", depth),
-		te.Error(),
-		fmt.Sprintf("%s:%d", file, line)))
-	children := te.Children()
-	for _, child := range children {
-		locations = append(locations, treeRepr(child, depth+1)...)
+// GoString implements fmt.GoStringer, returning a representation of e for
+// debugging with its message, location and number of children, which is also
+// used by the %#v verb.
+func (e *tracedError) GoString() string {
+	file, line := e.Location()
+	return fmt.Sprintf("&terr.tracedError{Message: %q, Location: \"%s:%d\", Children: %d}",
+		e.Error(), file, line, len(e.children))
+}
+
+// tree returns the representation of the error tracing tree rooted in e,
+// computing it only once per tree style.
+func (e *tracedError) tree() string {
+	return e.treeWith(getTreeStyle())
+}
+
+// treeWith works like tree, but uses the given style.
+func (e *tracedError) treeWith(style *TreeStyle) string {
+	internal := internalFilter(style)
+	if repr := e.repr.Load(); repr != nil && repr.style == style && repr.internal == internal {
+		return repr.s
 	}
-	return locations
+	repr := &treeRepr{style: style, internal: internal, s: renderTree(e, style)}
+	e.repr.Store(repr)
+	return repr.s
 }
 
 // Newf works exactly like fmt.Errorf, but returns a traced error. All traced
 // errors passed as formatting arguments are included as children, regardless
-// of the formatting verbs used for these errors.
-// This function is equivalent to fmt.Errorf("...", ...). If used without verbs
+// of the formatting verbs used for these errors. Errors combining multiple
+// errors, like the ones returned by errors.Join, multierr.Combine or
+// Kubernetes' NewAggregate, are expanded, so each combined error is included
+// as a separate child, with untraced ones located where Newf is. So are
+// []error arguments, which can be wrapped with the %w verb by passing them to
+// Join first. Newf is recognized as a printf wrapper by go vet, so its format
+// strings and arguments are checked as for fmt.Errorf. This function is equivalent to fmt.Errorf("...", ...). If used without verbs
 // and additional arguments, it is equivalent to errors.New("...").
 func Newf(format string, a ...any) error {
-	return newTracedError(fmt.Errorf(format, a...), a, getCallerLocation(0))
+	return newf(fmt.Errorf(format, a...), a, nil)
+}
+
+// newf returns a traced error for err, which was formatted by the calling
+// function with the arguments a, with opts applied to it and located where
+// that function was called. Exported functions formatting errors call
+// fmt.Errorf with their format and arguments as given, so go vet recognizes
+// them as printf wrappers and checks their calls.
+func newf(err error, a []any, opts []TraceOption) error {
+	if te := newTracedError(err, a, 1, opts); te != nil {
+		return te
+	}
+	return err
+}
+
+// NewfWith works exactly like Newf, but accepts options to customize the
+// returned traced error, so error constructors can combine formatting with
+// options in a single step.
+func NewfWith(opts []TraceOption, format string, a ...any) error {
+	return newf(fmt.Errorf(format, a...), a, opts)
+}
+
+// TraceOption is an option that can be passed to NewfWith, Trace and
+// TraceSkip to customize the traced error being returned.
+type TraceOption func(*tracedError)
+
+// WithChildren adds children to the traced error, besides the ones it already
+// includes. Nil children are ignored. This can be used to re-attach error
+// tracing trees obtained elsewhere to new traced errors.
+func WithChildren(children ...ErrorTracer) TraceOption {
+	return func(e *tracedError) {
+		for _, child := range children {
+			if child != nil {
+				e.addChild(child)
+			}
+		}
+	}
+}
+
+// WithLocation sets the location of the traced error to the given file and
+// line, instead of the location where it was created. This can be used when
+// the actual location of an error is known by other means.
+func WithLocation(file string, line int) TraceOption {
+	return func(e *tracedError) {
+		e.loc.Store(&location{file: file, line: line})
+	}
 }
 
 // Trace returns a new traced error for err. If err is already a traced error,
 // a new traced error will be returned containing err as a child traced error.
-// No wrapping or masking takes place in this function.
-func Trace(err error) error {
+// If err combines multiple errors, they are expanded as in Newf.
+// No wrapping or masking takes place in this function. Options can be used to
+// customize the returned traced error.
+func Trace(err error, opts ...TraceOption) error {
 	if err == nil {
 		return nil
 	}
-	return newTracedError(err, []any{err}, getCallerLocation(0))
+	if te := newTracedError(err, []any{err}, 0, opts); te != nil {
+		return te
+	}
+	return err
 }
 
 // TraceSkip works exactly like Trace, but lets the caller skip a number of
 // stack frames when detecting the error location, with 0 identifying the
 // caller of TraceSkip. This function can be used in custom error constructor
 // functions, so they can return a traced error pointing at their callers.
-func TraceSkip(err error, skip int) error {
+func TraceSkip(err error, skip int, opts ...TraceOption) error {
 	if err == nil {
 		return nil
 	}
-	return newTracedError(err, []any{err}, getCallerLocation(skip))
+	if te := newTracedError(err, []any{err}, skip, opts); te != nil {
+		return te
+	}
+	return err
 }
 
 // ErrorTracer is an object capable of tracing an error's location and possibly
@@ -142,13 +489,41 @@ type ErrorTracer interface {
 	Children() []ErrorTracer
 }
 
-// TraceTree returns the root of the n-ary error tracing tree for err. Returns
-// nil if err is not a traced error. This function can be used to represent the
-// error tracing tree using custom formats.
+// FuncTracer is an ErrorTracer that also knows the function where it was
+// created. All traced errors created by this package implement it.
+type FuncTracer interface {
+	ErrorTracer
+	// Func returns the fully qualified name of the function where the error
+	// was created (e.g., "github.com/user/app/db.(*Store).Get"), or an empty
+	// string if it is unknown.
+	Func() string
+}
+
+// funcOf returns the function of et if it implements FuncTracer, or an empty
+// string otherwise.
+func funcOf(et ErrorTracer) string {
+	if ft, ok := et.(FuncTracer); ok {
+		return ft.Func()
+	}
+	return ""
+}
+
+// TraceTree returns the root of the n-ary error tracing tree for err. If err
+// is not a traced error, the nearest traced error wrapped by it is used, as
+// found by walking its Go error tree (as defined by errors.Unwrap and
+// Unwrap() []error) depth-first, so non-traced wrappers (e.g., fmt.Errorf
+// calls in third-party code) do not hide the trace. Returns nil if there is no
+// traced error in err. This function can be used to represent the error
+// tracing tree using custom formats.
 func TraceTree(err error) ErrorTracer {
-	te, _ := err.(*tracedError)
-	if te == nil {
-		return nil
+	if te := findTraced(err); te != nil {
+		return te
 	}
-	return te
+	return nil
+}
+
+// HasTrace returns whether err is a traced error or wraps one, i.e., whether
+// TraceTree returns an error tracing tree for it.
+func HasTrace(err error) bool {
+	return findTraced(err) != nil
 }
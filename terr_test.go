@@ -1,60 +1,19 @@
+//go:build !terr_noop
+
 package terr_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
-	"runtime"
+	"runtime/debug"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/alnvdl/terr"
 )
 
-func getLocation(depth int) (string, int) {
-	_, file, line, _ := runtime.Caller(depth + 1)
-	return file, line
-}
-
-func assertEquals[T comparable](t *testing.T, got, want T) {
-	if got != want {
-		t.Fatalf("want %#v got %#v", want, got)
-	}
-}
-
-func assertErrorIsNil(t *testing.T, got error) {
-	if got != nil {
-		t.Fatalf("want nil error, got %#v", got)
-	}
-}
-
-func assertTraceTreeEquals(t *testing.T, got terr.ErrorTracer, want terr.ErrorTracer) {
-	if got == nil && want == nil {
-		return
-	}
-	if got != nil && want == nil {
-		t.Fatalf("want traced error children is nil but got traced error children isn't nil")
-	}
-	if got == nil && want != nil {
-		t.Fatalf("want trace is not nil but got trace is nil")
-	}
-
-	gotFile, gotLine := got.Location()
-	wantFile, wantLine := want.Location()
-	assertEquals(t, gotFile, wantFile)
-	assertEquals(t, gotLine, wantLine)
-	assertEquals(t, got.Error(), want.Error())
-	if len(got.Children()) != len(want.Children()) {
-		t.Fatalf("want trace tree with %d children, got trace tree with %d children: want %#v got %#v ",
-			len(want.Children()),
-			len(got.Children()),
-			want.Children(),
-			got.Children())
-	}
-	for i := range got.Children() {
-		assertTraceTreeEquals(t, got.Children()[i], want.Children()[i])
-	}
-}
-
 func TestTrace(t *testing.T) {
 	file, line := getLocation(0)
 	err := terr.Newf("fail")
@@ -87,6 +46,16 @@ func TestTraceSkip(t *testing.T) {
 	}, "\n"))
 }
 
+func TestUnknownLocation(t *testing.T) {
+	// Skipping more frames than there are in the stack leaves the caller
+	// unknown, as in runtimes without caller information.
+	err := terr.TraceSkip(errors.New("fail"), 1000)
+	file, line := terr.TraceTree(err).Location()
+	assertEquals(t, file, "???")
+	assertEquals(t, line, 0)
+	assertEquals(t, fmt.Sprintf("%@", err), "fail @ ???:0")
+}
+
 type customError struct {
 	value string
 }
@@ -206,3 +175,289 @@ func TestNil(t *testing.T) {
 
 	assertTraceTreeEquals(t, terr.TraceTree(nil), nil)
 }
+
+func TestErrorsAsErrorTracer(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Newf("fail")
+	wrapped := fmt.Errorf("non-traced: %w", err)
+
+	var et terr.ErrorTracer
+	assertEquals(t, errors.As(wrapped, &et), true)
+	assertEquals(t, et.Error(), "fail")
+	gotFile, gotLine := et.Location()
+	assertEquals(t, gotFile, file)
+	assertEquals(t, gotLine, line+1)
+
+	et = nil
+	assertEquals(t, errors.As(errors.New("fail"), &et), false)
+	assertEquals(t, et == nil, true)
+}
+
+func TestWithChildren(t *testing.T) {
+	file, line := getLocation(0)
+	child1 := terr.Newf("child1")
+	child2 := terr.Newf("child2")
+	err := terr.Trace(errors.New("fail"), terr.WithChildren(terr.TraceTree(child1), nil, terr.TraceTree(child2)))
+
+	assertEquals(t, errors.Is(err, child1), false)
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("fail @ %s:%d", file, line+3),
+		fmt.Sprintf("\tchild1 @ %s:%d", file, line+1),
+		fmt.Sprintf("\tchild2 @ %s:%d", file, line+2),
+	}, "\n"))
+}
+
+func TestTraceTreeWrapped(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Newf("fail")
+	wrapped := fmt.Errorf("third-party: %w", fmt.Errorf("library: %w", err))
+
+	et := terr.TraceTree(wrapped)
+	assertEquals(t, et.Error(), "fail")
+	gotFile, gotLine := et.Location()
+	assertEquals(t, gotFile, file)
+	assertEquals(t, gotLine, line+1)
+	assertEquals(t, terr.HasTrace(wrapped), true)
+
+	joined := errors.Join(errors.New("other"), wrapped)
+	assertEquals(t, terr.TraceTree(joined) == et, true)
+
+	assertEquals(t, terr.TraceTree(errors.New("fail")) == nil, true)
+	assertEquals(t, terr.HasTrace(errors.New("fail")), false)
+	assertEquals(t, terr.HasTrace(nil), false)
+}
+
+func TestChildrenCopy(t *testing.T) {
+	err := terr.Newf("fail: %w", terr.Newf("child"))
+
+	children := terr.TraceTree(err).Children()
+	children[0] = nil
+	assertEquals(t, terr.TraceTree(err).Children()[0] != nil, true)
+	assertEquals(t, terr.TraceTree(terr.Newf("leaf")).Children() == nil, true)
+}
+
+type causer interface {
+	Cause() error
+}
+
+func TestCause(t *testing.T) {
+	base := errors.New("fail")
+	err := terr.Trace(terr.Trace(base))
+
+	var c causer
+	assertEquals(t, errors.As(err, &c), true)
+	cause := c.Cause()
+	assertEquals(t, cause.(causer).Cause(), base)
+}
+
+func TestNewfWith(t *testing.T) {
+	file, line := getLocation(0)
+	child := terr.Newf("child")
+	extra := terr.Newf("extra")
+	err := terr.NewfWith([]terr.TraceOption{
+		terr.WithChildren(terr.TraceTree(extra)),
+		terr.WithCode("failed"),
+	}, "fail: %w", child)
+
+	assertEquals(t, errors.Is(err, child), true)
+	assertEquals(t, terr.Code(err), "failed")
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("fail: child @ %s:%d", file, line+3),
+		fmt.Sprintf("\tchild @ %s:%d", file, line+1),
+		fmt.Sprintf("\textra @ %s:%d", file, line+2),
+	}, "\n"))
+
+	err = terr.NewfWith(nil, "fail")
+	assertEquals(t, fmt.Sprintf("%@", err), fmt.Sprintf("fail @ %s:%d", file, line+16))
+}
+
+func TestWithLocation(t *testing.T) {
+	err := terr.NewfWith([]terr.TraceOption{terr.WithLocation("remote.go", 42)}, "fail")
+
+	file, line := terr.TraceTree(err).Location()
+	assertEquals(t, file, "remote.go")
+	assertEquals(t, line, 42)
+}
+
+func TestFunc(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Newf("wrapped: %w", terr.NewfWith([]terr.TraceOption{terr.WithLocation("remote.go", 42)}, "fail"))
+
+	ft, ok := terr.TraceTree(err).(terr.FuncTracer)
+	assertEquals(t, ok, true)
+	assertEquals(t, ft.Func(), "github.com/alnvdl/terr_test.TestFunc")
+	child := ft.Children()[0].(terr.FuncTracer)
+	assertEquals(t, child.Func(), "")
+
+	terr.SetTreeStyle(terr.TreeStyle{Functions: true})
+	defer terr.SetTreeStyle(terr.TreeStyle{})
+	assertEquals(t, terr.Sprint(err), strings.Join([]string{
+		fmt.Sprintf("wrapped: fail @ %s:%d in github.com/alnvdl/terr_test.TestFunc", file, line+1),
+		"\tfail @ remote.go:42",
+	}, "\n"))
+
+	// Other tracers have no functions.
+	frames := terr.Frames(&traceTreeNode{err: "fail", file: "other.go", line: 1})
+	assertEquals(t, len(frames), 1)
+	assertEquals(t, frames[0].Function, "")
+}
+
+func TestLocationConcurrent(t *testing.T) {
+	file, line := getLocation(0)
+	newErr := func() error { return terr.Newf("fail") }
+	errs := make([]error, 100)
+	var wg sync.WaitGroup
+	for i := range errs {
+		errs[i] = newErr()
+		wg.Add(1)
+		go func(err error) {
+			defer wg.Done()
+			terr.TraceTree(err).Location()
+		}(errs[i])
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		gotFile, gotLine := terr.TraceTree(err).Location()
+		assertEquals(t, gotFile, file)
+		assertEquals(t, gotLine, line+1)
+	}
+}
+
+// deepTreeDepth is the depth of the branches of the tree returned by
+// deepTree.
+const deepTreeDepth = 4000
+
+// deepTree returns a traced error whose tree has two identical branches,
+// deepTreeDepth levels deep.
+func deepTree() error {
+	deep := func() terr.ErrorTracer {
+		et := terr.TraceTree(terr.Trace(errors.New("x"), terr.WithKind(terr.Timeout), terr.WithCode("deep")))
+		for i := 1; i < deepTreeDepth; i++ {
+			et = terr.TraceTree(terr.Trace(errors.New("x"), terr.WithChildren(et)))
+		}
+		return et
+	}
+	return terr.Trace(errors.New("root"), terr.WithChildren(deep(), deep()))
+}
+
+func TestDeepTree(t *testing.T) {
+	const depth = deepTreeDepth
+	err := deepTree()
+	b := terr.NewBuilder("x")
+	for i := 1; i < depth; i++ {
+		b = terr.NewBuilder("x").Child(b)
+	}
+
+	// Traversing the tree recursively would exceed this limit.
+	maxStack := debug.SetMaxStack(256 << 10)
+	defer debug.SetMaxStack(maxStack)
+	tree := terr.Sprint(err)
+	data := terr.Export(err)
+	canonical := terr.ExportCanonical(err)
+	yaml := terr.ExportYAML(err)
+	_, yamlErr := err.(interface{ MarshalYAML() (any, error) }).MarshalYAML()
+	logfmt := terr.ExportLogfmt(err)
+	appInsights := terr.ExportAppInsights(err)
+	ecs := terr.ExportECS(err)
+	gcp := terr.ExportGCP(err)
+	flamegraph := terr.ExportFlamegraph([]error{err})
+	signed := terr.SignTraceTree(data, []byte("key"))
+	verified, verifyErr := terr.VerifyTraceTree(signed, []byte("key"))
+	built := terr.Sprint(b.Build())
+	fingerprint := terr.Fingerprint(err)
+	kind, code := terr.KindOf(err), terr.Code(err)
+	debug.SetMaxStack(maxStack)
+
+	assertEquals(t, strings.Count(tree, "\n"), depth)
+	assertEquals(t, strings.Contains(tree, "(x2)"), true)
+	assertEquals(t, json.Valid(data), true)
+	assertEquals(t, strings.Count(string(data), `"message":"x"`), depth)
+	assertEquals(t, json.Valid(canonical), true)
+	assertEquals(t, strings.Count(string(canonical), `"message":"x"`), depth)
+	assertEquals(t, strings.Count(string(yaml), "message: \"x\""), depth)
+	assertEquals(t, terr.KindOf(yamlErr), terr.Invalid)
+	assertEquals(t, strings.Count(string(logfmt), "\n"), depth+1)
+	assertEquals(t, strings.Count(string(appInsights), `"typeName"`), depth+1)
+	assertEquals(t, json.Valid(ecs), true)
+	assertEquals(t, json.Valid(gcp), true)
+	assertEquals(t, strings.Count(string(flamegraph), `"value":2`), depth+2)
+	assertErrorIsNil(t, verifyErr)
+	assertEquals(t, string(verified), string(data))
+	assertEquals(t, strings.Count(built, "\n"), depth-1)
+	assertEquals(t, fingerprint != "", true)
+	assertEquals(t, kind, terr.Timeout)
+	assertEquals(t, code, "deep")
+}
+
+type anyNotFoundError struct{}
+
+func (anyNotFoundError) Error() string { return "not found" }
+
+func (anyNotFoundError) Is(target error) bool { return target == errNotFound }
+
+var errNotFound = errors.New("not found")
+
+type uncomparableError []string
+
+func (uncomparableError) Error() string { return "uncomparable" }
+
+func TestIs(t *testing.T) {
+	other := errors.New("other")
+	err := terr.Trace(errNotFound)
+	inner := err
+	for i := 0; i < 10; i++ {
+		err = terr.Newf("level %d: %w", i, err)
+	}
+	joined := terr.Newf("joined: %w", errors.Join(other, err))
+
+	for i := 0; i < 2; i++ {
+		assertEquals(t, errors.Is(err, errNotFound), true)
+		assertEquals(t, errors.Is(err, inner), true)
+		assertEquals(t, errors.Is(err, err), true)
+		assertEquals(t, errors.Is(err, other), false)
+		assertEquals(t, errors.Is(err, uncomparableError{"x"}), false)
+		assertEquals(t, errors.Is(joined, other), true)
+		assertEquals(t, errors.Is(joined, errNotFound), true)
+	}
+
+	custom := terr.Newf("custom: %w", terr.Trace(anyNotFoundError{}))
+	assertEquals(t, errors.Is(custom, errNotFound), true)
+	assertEquals(t, errors.Is(custom, other), false)
+}
+
+func BenchmarkIsDeep(b *testing.B) {
+	err := terr.Trace(errNotFound)
+	for i := 0; i < 20; i++ {
+		err = terr.Newf("level %d: %w", i, err)
+	}
+	other := errors.New("other")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = errors.Is(err, errNotFound)
+		_ = errors.Is(err, other)
+	}
+}
+
+func BenchmarkNewf(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = terr.Newf("fail")
+	}
+}
+
+func BenchmarkTrace(b *testing.B) {
+	err := errors.New("fail")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = terr.Trace(err)
+	}
+}
+
+func BenchmarkNewfLocation(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = terr.TraceTree(terr.Newf("fail")).Location()
+	}
+}
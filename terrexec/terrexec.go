@@ -0,0 +1,53 @@
+// Package terrexec implements helpers for tracing errors returned when
+// running external commands with os/exec.
+package terrexec
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/alnvdl/terr"
+)
+
+// MaxStderr is the maximum number of bytes of the standard error of a command
+// included in traced errors. Only the last bytes are kept, as they usually
+// contain the most relevant information about the failure.
+const MaxStderr = 512
+
+// Trace returns a traced error for err, which must have been returned when
+// running cmd, annotated with the following metadata:
+//   - "command": the base name of the command;
+//   - "exit_code": the command exit code, if it ran and exited;
+//   - "stderr": the last MaxStderr bytes of the command standard error, if
+//     it was captured either by cmd.Output or by setting cmd.Stderr to a
+//     *bytes.Buffer.
+//
+// Additional options are applied after the annotations. Returns nil if err is
+// nil. The location of the traced error is the caller of Trace.
+func Trace(cmd *exec.Cmd, err error, opts ...terr.TraceOption) error {
+	if err == nil {
+		return nil
+	}
+
+	annotations := []terr.TraceOption{
+		terr.WithMetadata("command", filepath.Base(cmd.Path)),
+	}
+	var stderr []byte
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		annotations = append(annotations, terr.WithMetadata("exit_code", exitErr.ExitCode()))
+		stderr = exitErr.Stderr
+	}
+	if buf, ok := cmd.Stderr.(*bytes.Buffer); ok && len(stderr) == 0 {
+		stderr = buf.Bytes()
+	}
+	if stderr = bytes.TrimSpace(stderr); len(stderr) > 0 {
+		if len(stderr) > MaxStderr {
+			stderr = append([]byte("..."), stderr[len(stderr)-MaxStderr:]...)
+		}
+		annotations = append(annotations, terr.WithMetadata("stderr", string(stderr)))
+	}
+	return terr.TraceSkip(err, 1, append(annotations, opts...)...)
+}
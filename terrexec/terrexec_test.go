@@ -0,0 +1,73 @@
+//go:build !terr_noop
+
+package terrexec_test
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+	"github.com/alnvdl/terr/terrexec"
+)
+
+func shell(t *testing.T, script string) *exec.Cmd {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	return exec.Command("sh", "-c", script)
+}
+
+func TestTrace(t *testing.T) {
+	cmd := shell(t, "echo ignored; echo oops >&2; exit 3")
+	_, err := cmd.Output()
+	_, file, line, _ := runtime.Caller(0)
+	tracedErr := terrexec.Trace(cmd, err, terr.WithCode("exec"))
+
+	et := terr.TraceTree(tracedErr)
+	gotFile, gotLine := et.Location()
+	if gotFile != file || gotLine != line+1 {
+		t.Fatalf("want location %s:%d, got %s:%d", file, line+1, gotFile, gotLine)
+	}
+	md := terr.Metadata(et)
+	if md["command"] != "sh" || md["exit_code"] != 3 || md["stderr"] != "oops" {
+		t.Fatalf("unexpected metadata: %v", md)
+	}
+	if terr.Code(tracedErr) != "exec" {
+		t.Fatalf("want code exec, got %q", terr.Code(tracedErr))
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(tracedErr, &exitErr) {
+		t.Fatalf("want *exec.ExitError to be found in %v", tracedErr)
+	}
+}
+
+func TestTraceStderrBuffer(t *testing.T) {
+	cmd := shell(t, "printf '%0600d' 0 >&2; exit 1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := terrexec.Trace(cmd, cmd.Run())
+
+	got := terr.Metadata(terr.TraceTree(err))["stderr"].(string)
+	if got != "..."+strings.Repeat("0", terrexec.MaxStderr) {
+		t.Fatalf("want truncated stderr, got %q", got)
+	}
+}
+
+func TestTraceNotFound(t *testing.T) {
+	cmd := exec.Command("terrexec-command-that-does-not-exist")
+	err := terrexec.Trace(cmd, cmd.Run())
+	md := terr.Metadata(terr.TraceTree(err))
+	if _, ok := md["exit_code"]; ok || md["command"] != "terrexec-command-that-does-not-exist" {
+		t.Fatalf("unexpected metadata: %v", md)
+	}
+}
+
+func TestTraceNil(t *testing.T) {
+	if err := terrexec.Trace(exec.Command("true"), nil); err != nil {
+		t.Fatalf("want nil error, got %v", err)
+	}
+}
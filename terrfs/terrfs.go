@@ -0,0 +1,85 @@
+// Package terrfs implements helpers for tracing errors returned by file
+// operations, annotating them with the paths involved.
+package terrfs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/alnvdl/terr"
+)
+
+// Trace returns a traced error for err, which should have been returned by a
+// file operation. If err is or wraps an *fs.PathError, the traced error has
+// the operation of the *fs.PathError (e.g., "open") and a "path" metadata
+// entry. If it is or wraps an *os.LinkError, it has the operation of the
+// *os.LinkError and "old_path" and "new_path" metadata entries.
+//
+// The traced error keeps matching fs.ErrNotExist and similar errors with
+// errors.Is. Additional options are applied after the annotations. Returns nil
+// if err is nil. The location of the traced error is the caller of Trace.
+func Trace(err error, opts ...terr.TraceOption) error {
+	return trace(err, 1, opts)
+}
+
+// trace returns a traced error for err, skipping a number of stack frames
+// like terr.TraceSkip.
+func trace(err error, skip int, opts []terr.TraceOption) error {
+	if err == nil {
+		return nil
+	}
+	var annotations []terr.TraceOption
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	if errors.As(err, &pathErr) {
+		annotations = append(annotations,
+			terr.WithOp(pathErr.Op),
+			terr.WithMetadata("path", pathErr.Path))
+	} else if errors.As(err, &linkErr) {
+		annotations = append(annotations,
+			terr.WithOp(linkErr.Op),
+			terr.WithMetadata("old_path", linkErr.Old),
+			terr.WithMetadata("new_path", linkErr.New))
+	}
+	return terr.TraceSkip(err, skip+1, append(annotations, opts...)...)
+}
+
+// Open works like os.Open, but errors are traced as with Trace.
+func Open(name string) (*os.File, error) {
+	f, err := os.Open(name)
+	return f, trace(err, 1, nil)
+}
+
+// Create works like os.Create, but errors are traced as with Trace.
+func Create(name string) (*os.File, error) {
+	f, err := os.Create(name)
+	return f, trace(err, 1, nil)
+}
+
+// ReadFile works like os.ReadFile, but errors are traced as with Trace.
+func ReadFile(name string) ([]byte, error) {
+	data, err := os.ReadFile(name)
+	return data, trace(err, 1, nil)
+}
+
+// WriteFile works like os.WriteFile, but errors are traced as with Trace.
+func WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return trace(os.WriteFile(name, data, perm), 1, nil)
+}
+
+// Stat works like os.Stat, but errors are traced as with Trace.
+func Stat(name string) (fs.FileInfo, error) {
+	info, err := os.Stat(name)
+	return info, trace(err, 1, nil)
+}
+
+// Remove works like os.Remove, but errors are traced as with Trace.
+func Remove(name string) error {
+	return trace(os.Remove(name), 1, nil)
+}
+
+// Rename works like os.Rename, but errors are traced as with Trace.
+func Rename(oldpath, newpath string) error {
+	return trace(os.Rename(oldpath, newpath), 1, nil)
+}
@@ -0,0 +1,88 @@
+//go:build !terr_noop
+
+package terrfs_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/alnvdl/terr"
+	"github.com/alnvdl/terr/terrfs"
+)
+
+func TestTrace(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	_, err := os.Open(missing)
+	_, file, line, _ := runtime.Caller(0)
+	tracedErr := terrfs.Trace(err, terr.WithCode("config"))
+
+	et := terr.TraceTree(tracedErr)
+	gotFile, gotLine := et.Location()
+	if gotFile != file || gotLine != line+1 {
+		t.Fatalf("want location %s:%d, got %s:%d", file, line+1, gotFile, gotLine)
+	}
+	if !errors.Is(tracedErr, fs.ErrNotExist) {
+		t.Fatalf("want fs.ErrNotExist, got %v", tracedErr)
+	}
+	if md := terr.Metadata(et); md["path"] != missing || terr.Op(et) != "open" {
+		t.Fatalf("unexpected annotations: %v, %q", md, terr.Op(et))
+	}
+	if terr.Code(tracedErr) != "config" {
+		t.Fatalf("want code config, got %q", terr.Code(tracedErr))
+	}
+	if terrfs.Trace(nil) != nil {
+		t.Fatal("want nil error")
+	}
+}
+
+func TestHelpers(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.txt")
+	existing := filepath.Join(dir, "existing.txt")
+
+	assertLocation := func(t *testing.T, err error, line int) {
+		t.Helper()
+		_, file, _, _ := runtime.Caller(0)
+		et := terr.TraceTree(err)
+		if et == nil {
+			t.Fatalf("want traced error, got %v", err)
+		}
+		gotFile, gotLine := et.Location()
+		if gotFile != file || gotLine != line {
+			t.Fatalf("want location %s:%d, got %s:%d", file, line, gotFile, gotLine)
+		}
+	}
+
+	if err := terrfs.WriteFile(existing, []byte("data"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, err := terrfs.ReadFile(existing); err != nil || string(data) != "data" {
+		t.Fatalf("unexpected read: %q, %v", data, err)
+	}
+
+	_, _, line, _ := runtime.Caller(0)
+	_, err := terrfs.Open(missing)
+	assertLocation(t, err, line+1)
+	_, err = terrfs.ReadFile(missing)
+	assertLocation(t, err, line+3)
+	_, err = terrfs.Stat(missing)
+	assertLocation(t, err, line+5)
+	err = terrfs.Remove(missing)
+	assertLocation(t, err, line+7)
+	_, err = terrfs.Create(filepath.Join(missing, "child.txt"))
+	assertLocation(t, err, line+9)
+	err = terrfs.WriteFile(filepath.Join(missing, "child.txt"), nil, 0o600)
+	assertLocation(t, err, line+11)
+	err = terrfs.Rename(missing, existing)
+	assertLocation(t, err, line+13)
+	if md := terr.Metadata(terr.TraceTree(err)); md["old_path"] != missing || md["new_path"] != existing {
+		t.Fatalf("unexpected metadata: %v", md)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("want fs.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Package terrio implements io.Reader and io.Writer wrappers returning traced
+// errors annotated with where in the stream they occurred.
+package terrio
+
+import (
+	"io"
+
+	"github.com/alnvdl/terr"
+)
+
+// Reader wraps an io.Reader, tracing the errors it returns. io.EOF is
+// returned unchanged, as callers are expected to compare it directly.
+type Reader struct {
+	r      io.Reader
+	name   string
+	offset int64
+}
+
+// NewReader returns a Reader reading from r. The name identifies the resource
+// being read (e.g., a file name or a remote address), and it is omitted from
+// traced errors if empty.
+func NewReader(r io.Reader, name string) *Reader {
+	return &Reader{r: r, name: name}
+}
+
+// Read implements io.Reader. Errors other than io.EOF are traced to the
+// caller of Read, with the "read" operation and the following metadata:
+//   - "offset": the offset in the stream where the error occurred;
+//   - "resource": the name of the resource, if set.
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.offset += int64(n)
+	if err != nil && err != io.EOF {
+		err = trace(err, "read", r.offset, r.name)
+	}
+	return n, err
+}
+
+// Offset returns the number of bytes read so far.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Writer wraps an io.Writer, tracing the errors it returns.
+type Writer struct {
+	w      io.Writer
+	name   string
+	offset int64
+}
+
+// NewWriter returns a Writer writing to w. The name identifies the resource
+// being written (e.g., a file name or a remote address), and it is omitted
+// from traced errors if empty.
+func NewWriter(w io.Writer, name string) *Writer {
+	return &Writer{w: w, name: name}
+}
+
+// Write implements io.Writer. Errors are traced to the caller of Write, with
+// the "write" operation and the following metadata:
+//   - "offset": the offset in the stream where the error occurred;
+//   - "resource": the name of the resource, if set.
+func (w *Writer) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.offset += int64(n)
+	if err != nil {
+		err = trace(err, "write", w.offset, w.name)
+	}
+	return n, err
+}
+
+// Offset returns the number of bytes written so far.
+func (w *Writer) Offset() int64 {
+	return w.offset
+}
+
+// trace returns a traced error for err located at the caller of the method
+// calling trace.
+func trace(err error, op string, offset int64, name string) error {
+	opts := []terr.TraceOption{
+		terr.WithOp(op),
+		terr.WithMetadata("offset", offset),
+	}
+	if name != "" {
+		opts = append(opts, terr.WithMetadata("resource", name))
+	}
+	return terr.TraceSkip(err, 2, opts...)
+}
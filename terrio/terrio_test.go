@@ -0,0 +1,102 @@
+//go:build !terr_noop
+
+package terrio_test
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/alnvdl/terr"
+	"github.com/alnvdl/terr/terrio"
+)
+
+func TestReader(t *testing.T) {
+	r := terrio.NewReader(io.LimitReader(strings.NewReader("hello world"), 5), "greeting.txt")
+	buf := make([]byte, 8)
+	_, err := io.ReadFull(r, buf)
+
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("want io.ErrUnexpectedEOF, got %v", err)
+	}
+	// io.EOF is not traced, so neither is the io.ErrUnexpectedEOF io.ReadFull
+	// creates from it.
+	if et := terr.TraceTree(err); et != nil {
+		t.Fatalf("io.ReadFull should not return a traced error, got %v", et)
+	}
+
+	r = terrio.NewReader(iotest.TimeoutReader(strings.NewReader("hello world")), "conn")
+	n, err := r.Read(buf)
+	if n != 8 || err != nil {
+		t.Fatalf("unexpected first read: %d, %v", n, err)
+	}
+	_, file, line, _ := runtime.Caller(0)
+	_, err = r.Read(buf)
+	et := terr.TraceTree(err)
+	if et == nil {
+		t.Fatalf("want traced error, got %v", err)
+	}
+	if !errors.Is(err, iotest.ErrTimeout) {
+		t.Fatalf("want iotest.ErrTimeout, got %v", err)
+	}
+	gotFile, gotLine := et.Location()
+	if gotFile != file || gotLine != line+1 {
+		t.Fatalf("want location %s:%d, got %s:%d", file, line+1, gotFile, gotLine)
+	}
+	md := terr.Metadata(et)
+	if md["offset"] != int64(8) || md["resource"] != "conn" || terr.Op(et) != "read" {
+		t.Fatalf("unexpected annotations: %v, %q", md, terr.Op(et))
+	}
+	if r.Offset() != 8 {
+		t.Fatalf("want offset 8, got %d", r.Offset())
+	}
+}
+
+func TestReaderEOF(t *testing.T) {
+	r := terrio.NewReader(strings.NewReader(""), "")
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("want io.EOF, got %v", err)
+	}
+}
+
+type failingWriter struct {
+	max int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.max {
+		n := w.max
+		w.max = 0
+		return n, io.ErrShortWrite
+	}
+	w.max -= len(p)
+	return len(p), nil
+}
+
+func TestWriter(t *testing.T) {
+	w := terrio.NewWriter(&failingWriter{max: 6}, "")
+	if _, err := w.Write([]byte("hello ")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, file, line, _ := runtime.Caller(0)
+	n, err := w.Write([]byte("world"))
+
+	if n != 0 || !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("unexpected write: %d, %v", n, err)
+	}
+	et := terr.TraceTree(err)
+	gotFile, gotLine := et.Location()
+	if gotFile != file || gotLine != line+1 {
+		t.Fatalf("want location %s:%d, got %s:%d", file, line+1, gotFile, gotLine)
+	}
+	md := terr.Metadata(et)
+	if _, ok := md["resource"]; ok || md["offset"] != int64(6) || terr.Op(et) != "write" {
+		t.Fatalf("unexpected annotations: %v, %q", md, terr.Op(et))
+	}
+	if w.Offset() != 6 {
+		t.Fatalf("want offset 6, got %d", w.Offset())
+	}
+}
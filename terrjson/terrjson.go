@@ -0,0 +1,134 @@
+// Package terrjson implements helpers for tracing errors returned when
+// decoding JSON documents with encoding/json.
+package terrjson
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/alnvdl/terr"
+)
+
+// Trace returns a traced error for err, which must have been returned when
+// decoding data with encoding/json. If err is or wraps a *json.SyntaxError or
+// a *json.UnmarshalTypeError, the traced error is annotated with the
+// following metadata:
+//   - "offset": the byte offset in data where the error occurred;
+//   - "path": an approximate JSON pointer (RFC 6901) to the value being
+//     decoded at that offset, like "/items/3/price".
+//
+// Additional options are applied after the annotations. Returns nil if err is
+// nil. The location of the traced error is the caller of Trace.
+func Trace(data []byte, err error, opts ...terr.TraceOption) error {
+	return trace(data, err, opts)
+}
+
+// trace returns a traced error for err located at the caller of the function
+// calling trace.
+func trace(data []byte, err error, opts []terr.TraceOption) error {
+	if err == nil {
+		return nil
+	}
+	var annotations []terr.TraceOption
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) {
+		// The offset of syntax errors includes the offending byte, which
+		// must not be considered when finding the path.
+		annotations = append(annotations,
+			terr.WithMetadata("offset", syntaxErr.Offset),
+			terr.WithMetadata("path", Pointer(data, syntaxErr.Offset-1)))
+	} else if errors.As(err, &typeErr) {
+		annotations = append(annotations,
+			terr.WithMetadata("offset", typeErr.Offset),
+			terr.WithMetadata("path", Pointer(data, typeErr.Offset)))
+	}
+	return terr.TraceSkip(err, 2, append(annotations, opts...)...)
+}
+
+// Unmarshal works like json.Unmarshal, but errors are traced as with Trace.
+func Unmarshal(data []byte, v any) error {
+	return trace(data, json.Unmarshal(data, v), nil)
+}
+
+// frame is an array or object being scanned by Pointer.
+type frame struct {
+	array bool
+	// index is the index of the current element in arrays.
+	index int
+	// key is the key of the current member in objects, if it has been read.
+	key    string
+	hasKey bool
+}
+
+// pointerEscaper escapes keys in JSON pointers.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// Pointer returns an approximate JSON pointer (RFC 6901) to the value being
+// decoded at offset in data, based on the arrays and objects open at that
+// offset. It is meant for annotating errors, and it does not validate data.
+func Pointer(data []byte, offset int64) string {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	var stack []frame
+	for i := 0; i < int(offset); i++ {
+		switch data[i] {
+		case '{':
+			stack = append(stack, frame{})
+		case '[':
+			stack = append(stack, frame{array: true})
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case ',':
+			if len(stack) > 0 {
+				top := &stack[len(stack)-1]
+				top.index++
+				top.hasKey = false
+			}
+		case '"':
+			end := stringEnd(data, i)
+			if len(stack) > 0 {
+				top := &stack[len(stack)-1]
+				if !top.array && !top.hasKey {
+					var key string
+					if json.Unmarshal(data[i:end], &key) != nil {
+						key = string(data[i+1 : end-1])
+					}
+					top.key, top.hasKey = key, true
+				}
+			}
+			i = end - 1
+		}
+	}
+
+	var b strings.Builder
+	for _, f := range stack {
+		if f.array {
+			b.WriteByte('/')
+			b.WriteString(strconv.Itoa(f.index))
+		} else if f.hasKey {
+			b.WriteByte('/')
+			b.WriteString(pointerEscaper.Replace(f.key))
+		}
+	}
+	return b.String()
+}
+
+// stringEnd returns the offset right after the end of the JSON string
+// starting at offset start in data, or len(data) if it is not terminated.
+func stringEnd(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
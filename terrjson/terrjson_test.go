@@ -0,0 +1,89 @@
+//go:build !terr_noop
+
+package terrjson_test
+
+import (
+	"encoding/json"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/alnvdl/terr"
+	"github.com/alnvdl/terr/terrjson"
+)
+
+func TestPointer(t *testing.T) {
+	tests := []struct {
+		data   string
+		offset int64
+		want   string
+	}{
+		{`{"a": 1}`, 7, "/a"},
+		{`{"a": {"b": [1, 2, "x"]}}`, 22, "/a/b/2"},
+		{`{"a": {"b": 1}, "c": true}`, 25, "/c"},
+		{`[{"a": 1}, {"b": "x"}]`, 20, "/1/b"},
+		{`{"a/b": {"c~d": 1}}`, 17, "/a~1b/c~0d"},
+		{`{"a\"b": 1}`, 10, "/a\"b"},
+		{`{"a": "}", "b": 1}`, 17, "/b"},
+		{`{"a": 1, `, 9, ""},
+		{`[1, 2]`, 100, ""},
+	}
+	for _, test := range tests {
+		if got := terrjson.Pointer([]byte(test.data), test.offset); got != test.want {
+			t.Errorf("Pointer(%q, %d): want %q, got %q", test.data, test.offset, test.want, got)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	data := []byte(`{"items": [{"price": 1}, {"price": "free"}]}`)
+	var v struct {
+		Items []struct {
+			Price int `json:"price"`
+		} `json:"items"`
+	}
+	_, file, line, _ := runtime.Caller(0)
+	err := terrjson.Unmarshal(data, &v)
+
+	et := terr.TraceTree(err)
+	if et == nil {
+		t.Fatalf("want traced error, got %v", err)
+	}
+	gotFile, gotLine := et.Location()
+	if gotFile != file || gotLine != line+1 {
+		t.Fatalf("want location %s:%d, got %s:%d", file, line+1, gotFile, gotLine)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("want *json.UnmarshalTypeError, got %v", err)
+	}
+	if md := terr.Metadata(et); md["path"] != "/items/1/price" || md["offset"] != typeErr.Offset {
+		t.Fatalf("unexpected metadata: %v", md)
+	}
+	if terrjson.Unmarshal([]byte(`{}`), &v) != nil {
+		t.Fatal("want nil error")
+	}
+}
+
+func TestTrace(t *testing.T) {
+	data := []byte(`{"a": [1, 2,, 3]}`)
+	var v any
+	err := json.Unmarshal(data, &v)
+	tracedErr := terrjson.Trace(data, err, terr.WithCode("bad_payload"))
+
+	et := terr.TraceTree(tracedErr)
+	if md := terr.Metadata(et); md["path"] != "/a/2" || md["offset"] != int64(13) {
+		t.Fatalf("unexpected metadata: %v", md)
+	}
+	if terr.Code(tracedErr) != "bad_payload" {
+		t.Fatalf("want code bad_payload, got %q", terr.Code(tracedErr))
+	}
+
+	other := terrjson.Trace(data, errors.New("fail"))
+	if md := terr.Metadata(terr.TraceTree(other)); len(md) != 0 {
+		t.Fatalf("unexpected metadata: %v", md)
+	}
+	if terrjson.Trace(data, nil) != nil {
+		t.Fatal("want nil error")
+	}
+}
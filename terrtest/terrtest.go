@@ -0,0 +1,29 @@
+// Package terrtest implements test helpers reporting the error tracing trees
+// of unexpected errors.
+package terrtest
+
+import (
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+// Must fails and stops the test if err is not nil, reporting the error
+// tracing tree for err, as printed by terr.FormatAny, at the caller of Must.
+func Must(t testing.TB, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error:\n%s", terr.FormatAny(err))
+	}
+}
+
+// Check works like Must, but the test continues running after failing. It
+// returns whether err is nil.
+func Check(t testing.TB, err error) bool {
+	t.Helper()
+	if err != nil {
+		t.Errorf("unexpected error:\n%s", terr.FormatAny(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+//go:build !terr_noop
+
+package terrtest_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/alnvdl/terr"
+	"github.com/alnvdl/terr/terrtest"
+)
+
+// fakeTB records failures instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	helpers int
+	fatal   string
+	errs    []string
+}
+
+func (t *fakeTB) Helper() { t.helpers++ }
+
+func (t *fakeTB) Fatalf(format string, args ...any) {
+	t.fatal = fmt.Sprintf(format, args...)
+}
+
+func (t *fakeTB) Errorf(format string, args ...any) {
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
+
+func TestMust(t *testing.T) {
+	var tb fakeTB
+	terrtest.Must(&tb, nil)
+	if tb.fatal != "" {
+		t.Fatalf("Must failed for a nil error: %s", tb.fatal)
+	}
+
+	_, file, line, _ := runtime.Caller(0)
+	err := terr.Trace(errors.New("fail"))
+	terrtest.Must(&tb, err)
+	want := fmt.Sprintf("unexpected error:\nfail @ %s:%d", file, line+1)
+	if tb.fatal != want {
+		t.Fatalf("want %q, got %q", want, tb.fatal)
+	}
+	if tb.helpers != 2 {
+		t.Fatalf("want Helper called twice, got %d", tb.helpers)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var tb fakeTB
+	if !terrtest.Check(&tb, nil) || len(tb.errs) != 0 {
+		t.Fatalf("Check failed for a nil error: %v", tb.errs)
+	}
+
+	if terrtest.Check(&tb, errors.New("plain")) {
+		t.Fatalf("Check succeeded for a non-nil error")
+	}
+	if len(tb.errs) != 1 || tb.errs[0] != "unexpected error:\nplain" {
+		t.Fatalf("unexpected failures: %q", tb.errs)
+	}
+}
@@ -0,0 +1,179 @@
+package terr
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MarshalText implements encoding.TextMarshaler, encoding the error tracing
+// tree rooted in e as printed by the %@ verb with the default TreeStyle,
+// regardless of the style set with SetTreeStyle, so the text is stable.
+// Internal traced errors are included, as with the %+@ verb, but functions
+// are not, so the text can be decoded with ParseTraceTree.
+func (e *tracedError) MarshalText() ([]byte, error) {
+	e.report()
+	return []byte(e.treeWith(&verboseTreeStyle)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, decoding an error
+// tracing tree encoded by MarshalText into e, as ParseTraceTree does. Since
+// traced errors are immutable, it must only be used to decode into new
+// traced errors.
+func (e *tracedError) UnmarshalText(text []byte) error {
+	te, err := parseTraceTree(text)
+	if err != nil {
+		return err
+	}
+	e.error, e.id, e.metadata, e.hints, e.url = te.error, te.id, te.metadata, te.hints, te.url
+	e.children, e.omitted = te.children, te.omitted
+	e.loc.Store(te.loc.Load())
+	return nil
+}
+
+// nodeLinePattern matches the line of a traced error in the text format,
+// capturing its message, file, line, metadata and count.
+var nodeLinePattern = regexp.MustCompile(`^(.*) @ (.+?):(\d+)(?: \[(.*)\])?(?: \(x(\d+)(?:, same root cause)?\))?$`)
+
+// leafLinePattern matches the line of an untraced leaf in the text format,
+// which has no location, capturing its message, metadata and count.
+var leafLinePattern = regexp.MustCompile(`^(.*?)(?: \[(.*)\])?(?: \(x(\d+)(?:, same root cause)?\))?$`)
+
+// omittedLinePattern matches the line with the number of omitted children of
+// a traced error in the text format.
+var omittedLinePattern = regexp.MustCompile(`^\((\d+) more children omitted\)$`)
+
+// ParseTraceTree decodes an error tracing tree from its text format, as
+// printed by the %@ verb with the default TreeStyle or encoded by the
+// MarshalText method of traced errors. Messages, locations, metadata (as
+// strings), IDs, hints, URLs, repeated children and the number of omitted
+// children are decoded, while other properties are not part of the text
+// format. Lines without a location are decoded as untraced leaves, except
+// for the root. Messages spanning multiple lines are not supported. Malformed
+// input is rejected with a traced error of kind Invalid wrapping
+// ErrInvalidTraceTree, which describes the problem and the line where it was
+// found.
+func ParseTraceTree(text []byte) (ErrorTracer, error) {
+	te, err := parseTraceTree(text)
+	if err != nil {
+		return nil, err
+	}
+	return te, nil
+}
+
+// parseTraceTree works like ParseTraceTree, but returns a *tracedError.
+func parseTraceTree(text []byte) (*tracedError, error) {
+	invalid := func(n int, problem string) error {
+		return NewfWith([]TraceOption{WithKind(Invalid)}, "%w: %s at line %d", ErrInvalidTraceTree, problem, n)
+	}
+	if len(text) == 0 {
+		return nil, invalid(1, "missing traced error")
+	}
+	// parents holds the last traced error parsed at each depth.
+	var parents []*tracedError
+	var root *tracedError
+	lines := bytes.Split(bytes.TrimSuffix(text, []byte("\n")), []byte("\n"))
+	for i, b := range lines {
+		n := i + 1
+		line := string(b)
+		depth := len(line) - len(strings.TrimLeft(line, "\t"))
+		line = line[depth:]
+		if depth > len(parents) || (depth == 0 && root != nil) {
+			return nil, invalid(n, "unexpected indentation")
+		}
+
+		// Details are indented as the children of the traced error they
+		// describe.
+		if depth > 0 {
+			parent := parents[depth-1]
+			switch {
+			case strings.HasPrefix(line, "(hint: ") && strings.HasSuffix(line, ")"):
+				parent.hints = append(parent.hints, line[len("(hint: "):len(line)-1])
+				continue
+			case strings.HasPrefix(line, "(see: ") && strings.HasSuffix(line, ")"):
+				parent.url = line[len("(see: ") : len(line)-1]
+				continue
+			}
+			if m := omittedLinePattern.FindStringSubmatch(line); m != nil {
+				parent.omitted, _ = strconv.Atoi(m[1])
+				continue
+			}
+		}
+
+		m := nodeLinePattern.FindStringSubmatch(line)
+		if m == nil && depth > 0 {
+			if leaf := leafLinePattern.FindStringSubmatch(line); leaf != nil {
+				m = []string{leaf[0], leaf[1], "", "0", leaf[2], leaf[3]}
+			}
+		}
+		if m == nil {
+			return nil, invalid(n, "malformed traced error")
+		}
+		te := &tracedError{error: errors.New(m[1])}
+		lineNumber, err := strconv.Atoi(m[3])
+		if err != nil {
+			return nil, invalid(n, "malformed line number")
+		}
+		te.loc.Store(&location{file: m[2], line: lineNumber})
+		if m[4] != "" {
+			if err := parseMetadataText(te, m[4]); err != nil {
+				return nil, invalid(n, err.Error())
+			}
+		}
+		count := 1
+		if m[5] != "" {
+			if count, err = strconv.Atoi(m[5]); err != nil || count < 1 {
+				return nil, invalid(n, "malformed count")
+			}
+		}
+
+		parents = append(parents[:depth], te)
+		if depth == 0 {
+			root = te
+			continue
+		}
+		for j := 0; j < count; j++ {
+			parents[depth-1].addChild(te)
+		}
+	}
+	return root, nil
+}
+
+// parseMetadataText parses the ID and metadata of te in the "key=value"
+// format, as appended by appendMetadataText.
+func parseMetadataText(te *tracedError, text string) error {
+	for i := 0; text != ""; i++ {
+		if i > 0 {
+			if text[0] != ' ' {
+				return errors.New("malformed metadata")
+			}
+			text = text[1:]
+		}
+		key, rest, ok := strings.Cut(text, "=")
+		if !ok || key == "" {
+			return errors.New("malformed metadata")
+		}
+		value := rest
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return errors.New("malformed metadata value")
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		} else if end := strings.IndexByte(rest, ' '); end >= 0 {
+			value, rest = rest[:end], rest[end:]
+		} else {
+			rest = ""
+		}
+		if i == 0 && key == "id" {
+			te.id = value
+		} else {
+			te.setMetadata(key, value)
+		}
+		text = rest
+	}
+	return nil
+}
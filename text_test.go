@@ -0,0 +1,71 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestMarshalText(t *testing.T) {
+	terr.SetTreeStyle(terr.TreeStyle{Connectors: true})
+	defer terr.SetTreeStyle(terr.TreeStyle{})
+	terr.SetMaxChildren(3)
+	defer terr.SetMaxChildren(0)
+
+	leaf := terr.Trace(errors.New("timeout @ db"),
+		terr.WithHint("retry later"),
+		terr.WithURL("https://example.com/runbook"),
+		terr.WithMetadata("table", "users"),
+		terr.WithMetadata("query", "select [x] = 1"),
+	)
+	err := terr.Newf("query: %w, %w, %w, %w", leaf, leaf, terr.Newf("other"), terr.Newf("omitted"))
+	et := terr.TraceTree(err)
+
+	text, marshalErr := et.(interface{ MarshalText() ([]byte, error) }).MarshalText()
+	assertErrorIsNil(t, marshalErr)
+	terr.SetTreeStyle(terr.TreeStyle{})
+	assertEquals(t, string(text), terr.Sprint(err))
+
+	parsed, parseErr := terr.ParseTraceTree(text)
+	assertErrorIsNil(t, parseErr)
+	assertEquals(t, terr.Sprint(parsed.(error)), string(text))
+	assertEquals(t, len(parsed.Children()), 3)
+	assertEquals(t, terr.OmittedChildren(parsed), 1)
+	assertEquals(t, terr.Metadata(parsed.Children()[0])["query"], any("select [x] = 1"))
+	assertEquals(t, terr.Hints(parsed.Children()[0])[0], "retry later")
+	assertEquals(t, terr.URL(parsed.Children()[0]), "https://example.com/runbook")
+
+	decoded := terr.TraceTree(terr.Newf("new")).(interface{ UnmarshalText([]byte) error })
+	assertErrorIsNil(t, decoded.UnmarshalText(text))
+	assertEquals(t, fmt.Sprintf("%@", decoded), string(text))
+}
+
+func TestParseTraceTreeInvalid(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", "missing traced error at line 1"},
+		{"fail", "malformed traced error at line 1"},
+		{"fail @ a.go:1\n\t\tchild @ a.go:2", "unexpected indentation at line 2"},
+		{"fail @ a.go:1\nother @ a.go:2", "unexpected indentation at line 2"},
+		{"fail @ a.go:1\n\tchild @ a.go:2 (x0)", "malformed count at line 2"},
+		{"fail @ a.go:1 [k]", "malformed metadata at line 1"},
+		{`fail @ a.go:1 [k="v]`, "malformed metadata value at line 1"},
+	}
+	for _, test := range tests {
+		et, err := terr.ParseTraceTree([]byte(test.text))
+		if et != nil || err == nil {
+			t.Fatalf("want error for %q", test.text)
+		}
+		assertEquals(t, errors.Is(err, terr.ErrInvalidTraceTree), true)
+		if !strings.Contains(err.Error(), test.want) {
+			t.Fatalf("want error containing %q for %q, got %q", test.want, test.text, err.Error())
+		}
+	}
+}
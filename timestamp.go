@@ -0,0 +1,26 @@
+package terr
+
+import "time"
+
+// WithTime sets t as the time when the traced error occurred, flagging it as
+// imported. It is meant for traced errors imported from external data (e.g.,
+// built with a Builder from log entries of remote systems), so error tracing
+// trees merging errors from multiple systems keep an accurate chronology.
+// Traced errors created by this package are not timestamped, since their
+// order is implied by their trees. The time is included in structured output,
+// along with the imported flag.
+func WithTime(t time.Time) TraceOption {
+	return func(e *tracedError) {
+		e.time = t
+	}
+}
+
+// Time returns the time set for et with WithTime, and whether et was
+// imported with such a time.
+func Time(et ErrorTracer) (time.Time, bool) {
+	te, ok := et.(*tracedError)
+	if !ok || te.time.IsZero() {
+		return time.Time{}, false
+	}
+	return te.time, true
+}
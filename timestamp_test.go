@@ -0,0 +1,36 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestWithTime(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 30, 0, 500, time.UTC)
+	err := terr.NewBuilder("gateway failed").Location("gateway.go", 10).Child(
+		terr.NewBuilder("upstream failed").Location("upstream.go", 20).Time(ts),
+	).Build()
+
+	data := terr.Export(err)
+	assertEquals(t, string(data), `{"message":"gateway failed","file":"gateway.go","line":10,"children":[`+
+		`{"message":"upstream failed","file":"upstream.go","line":20,"time":"2024-05-01T12:30:00.0000005Z","imported":true}]}`)
+
+	children := terr.TraceTree(err).Children()
+	got, ok := terr.Time(children[0])
+	assertEquals(t, got.Equal(ts), true)
+	assertEquals(t, ok, true)
+	_, ok = terr.Time(terr.TraceTree(err))
+	assertEquals(t, ok, false)
+	_, ok = terr.Time(terr.TraceTree(terr.NewfWith([]terr.TraceOption{terr.WithTime(ts)}, "fail")))
+	assertEquals(t, ok, true)
+
+	et, decodeErr := terr.DecodeTraceTree(data, terr.DecodeLimits{})
+	assertErrorIsNil(t, decodeErr)
+	got, ok = terr.Time(et.Children()[0])
+	assertEquals(t, got.Equal(ts), true)
+	assertEquals(t, ok, true)
+}
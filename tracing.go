@@ -0,0 +1,6 @@
+//go:build !terr_noop
+
+package terr
+
+// noop is whether tracing is compiled out with the terr_noop build tag.
+const noop = false
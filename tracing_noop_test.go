@@ -0,0 +1,24 @@
+//go:build terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestNoop(t *testing.T) {
+	base := errors.New("base")
+	err := terr.Newf("fail: %w", base)
+
+	assertEquals(t, err.Error(), "fail: base")
+	assertEquals(t, errors.Is(err, base), true)
+	assertEquals(t, fmt.Sprintf("%T", err), fmt.Sprintf("%T", fmt.Errorf("%w", base)))
+	assertEquals(t, terr.Trace(base), base)
+	assertEquals(t, terr.TraceSkip(base, 1), base)
+	assertEquals(t, terr.NewfWith(nil, "fail").Error(), "fail")
+	assertTraceTreeEquals(t, terr.TraceTree(err), nil)
+}
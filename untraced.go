@@ -0,0 +1,36 @@
+package terr
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// untracedLeaves is whether non-traced errors passed to Newf are included as
+// leaves, as set with SetUntracedLeaves.
+var untracedLeaves atomic.Bool
+
+// SetUntracedLeaves sets whether errors that are neither traced nor wrap
+// traced errors, like the ones returned by errors.New in third-party code,
+// are included as location-less leaves when passed to Newf and similar
+// functions, so the error tracing tree reflects every contributing error. By
+// default, they are only part of the message of the traced error. Leaves
+// are printed without a location, and exports leave out their file and line.
+// Errors wrapped by Trace and TraceSkip are never included as leaves of
+// their own traced errors.
+func SetUntracedLeaves(enabled bool) {
+	untracedLeaves.Store(enabled)
+}
+
+// untracedLeaf returns a location-less leaf for the untraced error err.
+func untracedLeaf(err error) *tracedError {
+	te := &tracedError{error: err}
+	te.loc.Store(&location{})
+	return te
+}
+
+// sameError returns whether a is the error b, without panicking for errors
+// of types that are not comparable.
+func sameError(a any, b error) bool {
+	t := reflect.TypeOf(b)
+	return reflect.TypeOf(a) == t && t.Comparable() && a == any(b)
+}
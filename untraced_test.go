@@ -0,0 +1,49 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestSetUntracedLeaves(t *testing.T) {
+	terr.SetUntracedLeaves(true)
+	defer terr.SetUntracedLeaves(false)
+
+	file, line := getLocation(0)
+	traced := terr.Newf("traced")
+	plain := errors.New("plain")
+	wrapper := fmt.Errorf("wrapper: %w", terr.Newf("wrapped"))
+	err := terr.Newf("failed: %w, %v, %w, %d", plain, traced, wrapper, 1)
+
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("failed: plain, traced, wrapper: wrapped, 1 @ %s:%d", file, line+4),
+		"\tplain",
+		fmt.Sprintf("\ttraced @ %s:%d", file, line+1),
+	}, "\n"))
+	assertEquals(t, fmt.Sprintf("%@", terr.Trace(plain)), fmt.Sprintf("plain @ %s:%d", file, line+11))
+
+	terr.SetUntracedLeaves(false)
+	assertEquals(t, fmt.Sprintf("%@", terr.Newf("failed: %w", plain)), fmt.Sprintf("failed: plain @ %s:%d", file, line+14))
+
+	leaf := terr.TraceTree(err).Children()[0]
+	assertEquals(t, string(terr.Export(leaf)), `{"message":"plain"}`)
+	assertEquals(t, string(terr.ExportLogfmt(leaf)), "node=0 depth=0 msg=plain\n")
+	text, textErr := err.(encoding.TextMarshaler).MarshalText()
+	assertErrorIsNil(t, textErr)
+	parsed, parseErr := terr.ParseTraceTree(text)
+	assertErrorIsNil(t, parseErr)
+	assertEquals(t, terr.Sprint(parsed), terr.Sprint(err))
+	data, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	decoded, decodeErr := terr.UnmarshalTraceTree(data)
+	assertErrorIsNil(t, decodeErr)
+	assertEquals(t, terr.Sprint(decoded), terr.Sprint(err))
+}
@@ -0,0 +1,49 @@
+package terr
+
+import "sync"
+
+// codeURLs holds the URLs registered with SetCodeURL, by code.
+var codeURLs sync.Map // map[string]string
+
+// WithURL sets a documentation or runbook URL for the traced error, so
+// whoever reads the error tracing tree can jump to the relevant remediation
+// documentation. It takes precedence over URLs registered with SetCodeURL.
+func WithURL(url string) TraceOption {
+	return func(e *tracedError) {
+		e.url = url
+	}
+}
+
+// SetCodeURL registers url as the documentation or runbook URL for all traced
+// errors with the given code, including its domain prefix if any, that have
+// no URL set with WithURL. An empty URL removes the registration. It is
+// meant to be called during program initialization, typically along with the
+// definition of an error catalog.
+func SetCodeURL(code, url string) {
+	if url == "" {
+		codeURLs.Delete(code)
+		return
+	}
+	codeURLs.Store(code, url)
+}
+
+// URL returns the documentation or runbook URL of et, as set with WithURL or
+// registered for its code with SetCodeURL, or an empty string if it has none.
+func URL(et ErrorTracer) string {
+	te, ok := et.(*tracedError)
+	if !ok {
+		return ""
+	}
+	return te.getURL()
+}
+
+// getURL returns the URL of e.
+func (e *tracedError) getURL() string {
+	if e.url != "" || e.code == "" {
+		return e.url
+	}
+	if url, ok := codeURLs.Load(e.fullCode()); ok {
+		return url.(string)
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestWithURL(t *testing.T) {
+	file, line := getLocation(0)
+	err := terr.Trace(errors.New("disk full"), terr.WithURL("https://runbooks.example.com/disk"))
+
+	assertEquals(t, terr.URL(terr.TraceTree(err)), "https://runbooks.example.com/disk")
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("disk full @ %s:%d", file, line+1),
+		"\t(see: https://runbooks.example.com/disk)",
+	}, "\n"))
+
+	b, jsonErr := json.Marshal(err)
+	assertErrorIsNil(t, jsonErr)
+	assertEquals(t, string(b), fmt.Sprintf(`{"message":"disk full","file":%q,"line":%d,"url":"https://runbooks.example.com/disk"}`,
+		file, line+1))
+}
+
+func TestSetCodeURL(t *testing.T) {
+	terr.SetCodeURL("billing.card_declined", "https://runbooks.example.com/cards")
+	defer terr.SetCodeURL("billing.card_declined", "")
+
+	err := terr.Trace(errors.New("declined"), terr.WithCode("card_declined"), terr.WithDomain("billing"))
+	assertEquals(t, terr.URL(terr.TraceTree(err)), "https://runbooks.example.com/cards")
+
+	err = terr.Trace(err, terr.WithCode("card_declined"), terr.WithDomain("billing"), terr.WithURL("https://other.example.com"))
+	assertEquals(t, terr.URL(terr.TraceTree(err)), "https://other.example.com")
+
+	terr.SetCodeURL("billing.card_declined", "")
+	err = terr.Trace(errors.New("declined"), terr.WithCode("card_declined"), terr.WithDomain("billing"))
+	assertEquals(t, terr.URL(terr.TraceTree(err)), "")
+	assertEquals(t, terr.URL(terr.TraceTree(terr.Newf("fail"))), "")
+}
@@ -0,0 +1,132 @@
+package terr
+
+import (
+	"errors"
+)
+
+// Key identifies a typed value attached to traced errors. Keys are meant to
+// be stored in package variables:
+//
+//	var RetryAfter = terr.NewKey[time.Duration]("retry_after")
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a Key for values of type T, stored in the traced error
+// metadata under name.
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Name returns the name of the key.
+func (k Key[T]) Name() string {
+	return k.name
+}
+
+// WithValue attaches value to the traced error under key. Values are stored
+// as metadata, so they are included when printing or emitting error tracing
+// trees.
+func WithValue[T any](key Key[T], value T) TraceOption {
+	return WithMetadata(key.name, value)
+}
+
+// ValueOf returns the value attached under key to the first traced error
+// that has it, searching the error tracing trees of all traced errors in the
+// Go error tree for err, so values survive wrapping even by non-traced
+// errors. Error tracing trees are searched depth-first, starting at their
+// roots, so outer values take precedence over inner ones.
+func ValueOf[T any](err error, key Key[T]) (T, bool) {
+	var value T
+	found := false
+	walkErrors(err, func(err error) bool {
+		te, ok := err.(*tracedError)
+		if !ok {
+			return true
+		}
+		found = walkTree(te, func(et ErrorTracer) bool {
+			for _, md := range metadataOf(et) {
+				if v, ok := md.value.(T); ok && md.key == key.name {
+					value = v
+					return false
+				}
+			}
+			return true
+		})
+		return !found
+	})
+	return value, found
+}
+
+// walkErrors calls fn for err and all errors in its Go error tree, as
+// defined by errors.Unwrap, Unwrap() []error and the extractors registered
+// with RegisterChildExtractor, in depth-first order. It stops as soon as fn
+// returns false, returning whether the walk stopped early.
+func walkErrors(err error, fn func(error) bool) bool {
+	for err != nil {
+		if !fn(err) {
+			return true
+		}
+		errs := extractedErrors(err)
+		if multi, ok := err.(interface{ Unwrap() []error }); ok && errs == nil {
+			errs = multi.Unwrap()
+		}
+		if errs != nil {
+			for _, e := range errs {
+				if walkErrors(e, fn) {
+					return true
+				}
+			}
+			return false
+		}
+		err = errors.Unwrap(err)
+	}
+	return false
+}
+
+// walkTree calls fn for all nodes in the error tracing tree rooted in et, in
+// depth-first order. It stops as soon as fn returns false, returning whether
+// the walk stopped early. Nodes are kept in an explicit stack, so arbitrarily
+// deep trees cannot overflow the goroutine stack.
+func walkTree(et ErrorTracer, fn func(ErrorTracer) bool) bool {
+	var buf [16]ErrorTracer
+	stack := append(buf[:0], et)
+	for len(stack) > 0 {
+		et := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !fn(et) {
+			return true
+		}
+		children := childrenOf(et)
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, children[i])
+		}
+	}
+	return false
+}
+
+// anyTraced returns whether match returns true for any traced error in the
+// error tracing trees found in the Go error tree of err.
+func anyTraced(err error, match func(*tracedError) bool) bool {
+	return walkErrors(err, func(err error) bool {
+		te, ok := err.(*tracedError)
+		if !ok {
+			return true
+		}
+		found := walkTree(te, func(et ErrorTracer) bool {
+			te, ok := et.(*tracedError)
+			return !ok || !match(te)
+		})
+		return !found
+	})
+}
+
+// findTraced returns the first traced error in the Go error tree of err, or
+// nil if there is none.
+func findTraced(err error) *tracedError {
+	var found *tracedError
+	walkErrors(err, func(err error) bool {
+		found, _ = err.(*tracedError)
+		return found == nil
+	})
+	return found
+}
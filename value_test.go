@@ -0,0 +1,52 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alnvdl/terr"
+)
+
+var retryAfter = terr.NewKey[time.Duration]("retry_after")
+
+type conflict struct {
+	id      string
+	version int
+}
+
+var conflictKey = terr.NewKey[conflict]("conflict")
+
+func TestValueOf(t *testing.T) {
+	err := terr.Trace(terr.Newf("rate limited"), terr.WithValue(retryAfter, 5*time.Second))
+	wrapped := fmt.Errorf("non-traced: %w", terr.Newf("traced: %w", err))
+	joined := errors.Join(errors.New("other"), wrapped)
+
+	v, ok := terr.ValueOf(joined, retryAfter)
+	assertEquals(t, ok, true)
+	assertEquals(t, v, 5*time.Second)
+	assertEquals(t, retryAfter.Name(), "retry_after")
+
+	_, ok = terr.ValueOf(joined, conflictKey)
+	assertEquals(t, ok, false)
+	_, ok = terr.ValueOf(nil, conflictKey)
+	assertEquals(t, ok, false)
+
+	// Values of different types under the same name are not returned.
+	_, ok = terr.ValueOf(err, terr.NewKey[string]("retry_after"))
+	assertEquals(t, ok, false)
+}
+
+func TestValueOfPrecedence(t *testing.T) {
+	inner := terr.Trace(errors.New("conflict"), terr.WithValue(conflictKey, conflict{"a", 1}))
+	outer := terr.Trace(inner, terr.WithValue(conflictKey, conflict{"a", 2}))
+
+	v, ok := terr.ValueOf(outer, conflictKey)
+	assertEquals(t, ok, true)
+	assertEquals(t, v, conflict{"a", 2})
+	v, _ = terr.ValueOf(inner, conflictKey)
+	assertEquals(t, v, conflict{"a", 1})
+}
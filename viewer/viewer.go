@@ -0,0 +1,117 @@
+// Package viewer implements an HTTP handler serving a page that displays
+// traced errors as expandable error tracing trees.
+//
+// The handler is meant to be mounted under a debug route, similarly to
+// net/http/pprof:
+//
+//	http.Handle("/debug/errors", viewer.Handler(source))
+//
+// The page accepts a "q" query parameter, which filters errors whose tracing
+// trees contain the given text in any message or location.
+package viewer
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/alnvdl/terr"
+)
+
+var page = template.Must(template.New("page").Funcs(template.FuncMap{
+	"location": func(et terr.ErrorTracer) string {
+		file, line := et.Location()
+		if file == "" {
+			return ""
+		}
+		return fmt.Sprintf("%s:%d", file, line)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Traced errors</title>
+<style>
+body { font-family: sans-serif; margin: 1em 2em; }
+ul { list-style: none; padding-left: 1.5em; }
+summary, .leaf { font-family: monospace; white-space: pre-wrap; }
+.location { color: #666; }
+</style>
+</head>
+<body>
+<h1>Traced errors</h1>
+<form method="get">
+<input type="search" name="q" value="{{.Query}}" placeholder="Filter by message or location">
+<button type="submit">Filter</button>
+</form>
+<p>Showing {{len .Errors}} of {{.Total}} errors.</p>
+<ul>
+{{range .Errors}}<li>{{if .Tree}}{{template "node" .Tree}}{{else}}<span class="leaf">{{.Err.Error}}</span>{{end}}</li>
+{{end}}</ul>
+</body>
+</html>
+{{define "node"}}{{if .Children}}<details open><summary>{{.Error}}{{with location .}} <span class="location">@ {{.}}</span>{{end}}</summary>
+<ul>{{range .Children}}<li>{{template "node" .}}</li>{{end}}</ul>
+</details>{{else}}<span class="leaf">{{.Error}}{{with location .}} <span class="location">@ {{.}}</span>{{end}}</span>{{end}}{{end}}`))
+
+type pageError struct {
+	Err  error
+	Tree terr.ErrorTracer
+}
+
+// Handler returns an http.Handler that displays the errors returned by
+// source, in the same order, every time the page is requested. Traced errors
+// are displayed as expandable error tracing trees, while non-traced errors
+// are displayed as plain messages.
+func Handler(source func() []error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errs := source()
+		query := r.URL.Query().Get("q")
+		var data struct {
+			Query  string
+			Total  int
+			Errors []pageError
+		}
+		data.Query = query
+		data.Total = len(errs)
+		for _, err := range errs {
+			if err == nil {
+				continue
+			}
+			e := pageError{Err: err, Tree: terr.TraceTree(err)}
+			if query != "" && !matches(e, query) {
+				continue
+			}
+			data.Errors = append(data.Errors, e)
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := page.Execute(w, data); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// matches returns whether query is found in any message or location in the
+// error tracing tree for e.
+func matches(e pageError, query string) bool {
+	if e.Tree == nil {
+		return strings.Contains(e.Err.Error(), query)
+	}
+	var match func(et terr.ErrorTracer) bool
+	match = func(et terr.ErrorTracer) bool {
+		file, line := et.Location()
+		if strings.Contains(et.Error(), query) ||
+			file != "" && strings.Contains(fmt.Sprintf("%s:%d", file, line), query) {
+			return true
+		}
+		for _, child := range et.Children() {
+			if match(child) {
+				return true
+			}
+		}
+		return false
+	}
+	return match(e.Tree)
+}
@@ -0,0 +1,53 @@
+//go:build !terr_noop
+
+package viewer_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+	"github.com/alnvdl/terr/viewer"
+)
+
+func get(t *testing.T, h http.Handler, target string) string {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Fatalf("want HTML content type, got %q", ct)
+	}
+	return rec.Body.String()
+}
+
+func TestHandler(t *testing.T) {
+	errs := []error{
+		terr.Newf("wrapped: %w", terr.Newf("<base>")),
+		terr.Newf("other"),
+		errors.New("non-traced"),
+	}
+	h := viewer.Handler(func() []error { return errs })
+
+	body := get(t, h, "/")
+	for _, s := range []string{
+		"Showing 3 of 3 errors.",
+		"<summary>wrapped: &lt;base&gt; <span",
+		"&lt;base&gt; <span",
+		"viewer_test.go:",
+		"non-traced",
+	} {
+		if !strings.Contains(body, s) {
+			t.Fatalf("want body containing %q, got:\n%s", s, body)
+		}
+	}
+
+	body = get(t, h, "/?q=base")
+	if !strings.Contains(body, "Showing 1 of 3 errors.") || strings.Contains(body, "other") {
+		t.Fatalf("want only the matching error, got:\n%s", body)
+	}
+}
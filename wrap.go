@@ -0,0 +1,39 @@
+package terr
+
+import (
+	"fmt"
+)
+
+// errorSlice is an error wrapping multiple errors, as returned by Join.
+type errorSlice []error
+
+// Join returns an error wrapping all non-nil errs, so a []error (e.g., the
+// results of a batch) can be wrapped with the %w verb in Newf and fmt.Errorf,
+// which do not accept []error arguments for it:
+//
+//	err := terr.Newf("%d uploads failed: %w", len(errs), terr.Join(errs...))
+//
+// Like other errors combining multiple errors, it is expanded by Newf and
+// Trace, so each error becomes a separate child. Unlike errors.Join, the
+// message of the returned error lists errs in a single line, as fmt formats
+// []error values (e.g., "[timeout not found]"), and it is never nil.
+func Join(errs ...error) error {
+	joined := make(errorSlice, 0, len(errs))
+	for _, err := range errs {
+		if err != nil {
+			joined = append(joined, err)
+		}
+	}
+	return joined
+}
+
+// Error implements the error interface, formatting the errors as fmt does for
+// a []error with the %v verb.
+func (e errorSlice) Error() string {
+	return fmt.Sprint([]error(e))
+}
+
+// Unwrap returns the errors for use with errors.Is and errors.As.
+func (e errorSlice) Unwrap() []error {
+	return e
+}
@@ -0,0 +1,46 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestJoin(t *testing.T) {
+	file, line := getLocation(0)
+	traced := terr.Newf("traced")
+	plain := errors.New("plain")
+	errs := []error{traced, nil, plain}
+	err := terr.Newf("batch %d: %w", 2, terr.Join(errs...))
+
+	assertEquals(t, err.Error(), "batch 2: [traced plain]")
+	assertEquals(t, errors.Is(err, traced), true)
+	assertEquals(t, errors.Is(err, plain), true)
+	assertEquals(t, fmt.Sprintf("%@", err), strings.Join([]string{
+		fmt.Sprintf("batch 2: [traced plain] @ %s:%d", file, line+4),
+		fmt.Sprintf("\ttraced @ %s:%d", file, line+1),
+		fmt.Sprintf("\tplain @ %s:%d", file, line+4),
+	}, "\n"))
+
+	err = fmt.Errorf("batch: %w", terr.Join(traced, plain))
+	assertEquals(t, err.Error(), "batch: [traced plain]")
+	assertEquals(t, errors.Is(err, traced), true)
+
+	assertEquals(t, terr.Join().Error(), "[]")
+	assertEquals(t, terr.Join(nil) != nil, true)
+}
+
+func TestNewfErrorSlice(t *testing.T) {
+	traced := terr.Newf("traced")
+	errs := []error{traced, errors.New("plain")}
+
+	err := terr.Newf("batch: %v", errs)
+	assertEquals(t, err.Error(), "batch: [traced plain]")
+	assertEquals(t, errors.Is(err, traced), false)
+	assertEquals(t, len(terr.TraceTree(err).Children()), 2)
+}
@@ -0,0 +1,224 @@
+package terr
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ExportYAML works exactly like Export, but returns a YAML document with the
+// same structure as the JSON representation, for tooling and fixtures based
+// on YAML. Returns nil if err is not a traced error.
+func ExportYAML(err error, opts ...ExportOption) []byte {
+	data := Export(err, opts...)
+	if data == nil {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, decErr := decodeOrdered(dec)
+	if decErr != nil {
+		// The JSON representation is always valid.
+		panic(decErr)
+	}
+	return appendYAML(nil, value)
+}
+
+// MarshalYAML implements the Marshaler interfaces of the most common YAML
+// libraries (e.g., gopkg.in/yaml.v3), encoding the error tracing tree rooted
+// in e with the same structure as its JSON representation. Keys may be
+// reordered by these libraries. Since the representation is decoded
+// recursively, trees deeper than the MaxDepth of DefaultDecodeLimits are
+// rejected with a traced error of kind Invalid.
+func (e *tracedError) MarshalYAML() (any, error) {
+	if max := DefaultDecodeLimits.MaxDepth; deeperThan(e, max) {
+		return nil, NewfWith([]TraceOption{WithKind(Invalid)}, "maximum depth of %d exceeded", max)
+	}
+	var value any
+	err := json.Unmarshal(Export(e), &value)
+	return value, err
+}
+
+// deeperThan returns whether the error tracing tree rooted in et, with et at
+// depth 1, is deeper than max. Nodes are kept in an explicit stack, so
+// arbitrarily deep trees cannot overflow the goroutine stack.
+func deeperThan(et ErrorTracer, max int) bool {
+	// depthItem is a node at a given depth.
+	type depthItem struct {
+		et    ErrorTracer
+		depth int
+	}
+	stack := []depthItem{{et, 1}}
+	for len(stack) > 0 {
+		item := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if item.depth > max {
+			return true
+		}
+		for _, child := range childrenOf(item.et) {
+			stack = append(stack, depthItem{child, item.depth + 1})
+		}
+	}
+	return false
+}
+
+// orderedMap is a JSON object whose keys keep their original order.
+type orderedMap []orderedEntry
+
+type orderedEntry struct {
+	key   string
+	value any
+}
+
+// decodeOrdered decodes the next JSON value from dec, decoding objects as
+// orderedMaps, arrays as []any and scalars as their JSON representations.
+// Objects and arrays being decoded are kept in an explicit stack, so
+// arbitrarily deep values cannot overflow the goroutine stack.
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	// orderedFrame is an object or array being decoded. key is the key of
+	// the value being decoded in objects, if it was already read.
+	type orderedFrame struct {
+		object orderedMap
+		array  []any
+		key    *string
+	}
+	var frames []orderedFrame
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n := len(frames); n > 0 && frames[n-1].object != nil && frames[n-1].key == nil && tok != json.Delim('}') {
+			key := tok.(string)
+			frames[n-1].key = &key
+			continue
+		}
+
+		var value any
+		switch tok {
+		case json.Delim('{'):
+			frames = append(frames, orderedFrame{object: orderedMap{}})
+			continue
+		case json.Delim('['):
+			frames = append(frames, orderedFrame{array: []any{}})
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			f := frames[len(frames)-1]
+			frames = frames[:len(frames)-1]
+			if value = f.array; f.object != nil {
+				value = f.object
+			}
+		case nil:
+			value = "null"
+		default:
+			if s, ok := tok.(string); ok {
+				value = string(appendJSONString(nil, s))
+			} else {
+				b, err := json.Marshal(tok)
+				if err != nil {
+					return nil, err
+				}
+				value = string(b)
+			}
+		}
+
+		if len(frames) == 0 {
+			return value, nil
+		}
+		f := &frames[len(frames)-1]
+		if f.object != nil {
+			f.object = append(f.object, orderedEntry{*f.key, value})
+			f.key = nil
+		} else {
+			f.array = append(f.array, value)
+		}
+	}
+}
+
+// plainYAMLKey matches keys that do not need to be quoted in YAML.
+var plainYAMLKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_./-]*$`)
+
+// appendYAML appends value, an orderedMap or []any, to dst in YAML block
+// style. Scalars are encoded as JSON, which is valid YAML. Objects and arrays
+// being appended are kept in an explicit stack, so arbitrarily deep values
+// cannot overflow the goroutine stack.
+func appendYAML(dst []byte, value any) []byte {
+	// yamlFrame is an object or array whose entries are being appended,
+	// indented by depth levels. If inline is true, its first line is not
+	// indented, so mappings can start on the same line as sequence markers.
+	type yamlFrame struct {
+		value  any
+		depth  int
+		inline bool
+		next   int
+	}
+	frames := []yamlFrame{{value: value}}
+	for len(frames) > 0 {
+		f := &frames[len(frames)-1]
+		indent := strings.Repeat("  ", f.depth)
+		var item any
+		switch value := f.value.(type) {
+		case orderedMap:
+			if f.next == len(value) {
+				frames = frames[:len(frames)-1]
+				continue
+			}
+			entry := value[f.next]
+			if f.next > 0 || !f.inline {
+				dst = append(dst, indent...)
+			}
+			if plainYAMLKey.MatchString(entry.key) {
+				dst = append(dst, entry.key...)
+			} else {
+				dst = appendJSONString(dst, entry.key)
+			}
+			dst = append(dst, ':')
+			item = entry.value
+		case []any:
+			if f.next == len(value) {
+				frames = frames[:len(frames)-1]
+				continue
+			}
+			item = value[f.next]
+			dst = append(dst, indent...)
+			dst = append(dst, '-')
+			if m, ok := item.(orderedMap); ok && len(m) > 0 {
+				f.next++
+				dst = append(dst, ' ')
+				frames = append(frames, yamlFrame{value: m, depth: f.depth + 1, inline: true})
+				continue
+			}
+		}
+		f.next++
+
+		// Append the value of the mapping entry or sequence item, whose key
+		// or marker was already appended, followed by a newline.
+		depth := f.depth + 1
+		switch v := item.(type) {
+		case orderedMap:
+			if len(v) == 0 {
+				dst = append(dst, " {}\n"...)
+				continue
+			}
+			dst = append(dst, '\n')
+			frames = append(frames, yamlFrame{value: v, depth: depth})
+		case []any:
+			if len(v) == 0 {
+				dst = append(dst, " []\n"...)
+				continue
+			}
+			dst = append(dst, '\n')
+			frames = append(frames, yamlFrame{value: v, depth: depth})
+		default:
+			dst = append(dst, ' ')
+			dst = append(dst, item.(string)...)
+			dst = append(dst, '\n')
+		}
+	}
+	return dst
+}
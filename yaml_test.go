@@ -0,0 +1,44 @@
+//go:build !terr_noop
+
+package terr_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alnvdl/terr"
+)
+
+func TestExportYAML(t *testing.T) {
+	file, line := getLocation(0)
+	leaf := terr.Trace(errors.New("timeout"), terr.WithMetadata("table", "users"), terr.WithHint("retry"))
+	err := terr.Newf("query: %w", leaf)
+
+	assertEquals(t, string(terr.ExportYAML(err)), strings.Join([]string{
+		`message: "query: timeout"`,
+		fmt.Sprintf("file: %q", file),
+		fmt.Sprintf("line: %d", line+2),
+		"children:",
+		`  - message: "timeout"`,
+		fmt.Sprintf("    file: %q", file),
+		fmt.Sprintf("    line: %d", line+1),
+		"    hints:",
+		`      - "retry"`,
+		"    metadata:",
+		`      table: "users"`,
+		"",
+	}, "\n"))
+	assertEquals(t, terr.ExportYAML(errors.New("fail")) == nil, true)
+}
+
+func TestMarshalYAML(t *testing.T) {
+	err := terr.Trace(errors.New("fail"), terr.WithCode("failed"))
+
+	value, yamlErr := terr.TraceTree(err).(interface{ MarshalYAML() (any, error) }).MarshalYAML()
+	assertErrorIsNil(t, yamlErr)
+	m := value.(map[string]any)
+	assertEquals(t, m["message"], any("fail"))
+	assertEquals(t, m["code"], any("failed"))
+}